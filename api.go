@@ -0,0 +1,411 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// maxAPIRequestBodySize limits the size of the admin API request bodies.
+const maxAPIRequestBodySize = 64 << 10 // 64 KB
+
+// captchaPageTemplate renders the page where pending captchas can be solved.
+var captchaPageTemplate = template.Must(template.New("captcha").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>hh-resume-auto-boost: captcha</title>
+</head>
+<body>
+{{- range . }}
+<form method="post" action="/captcha">
+<p>{{ . }}</p>
+<img src="/captcha/image?login={{ . }}" alt="captcha">
+<input type="hidden" name="login" value="{{ . }}">
+<input type="text" name="answer" autocomplete="off" autofocus>
+<input type="submit" value="Submit">
+</form>
+{{- else }}
+<p>No captchas are pending.</p>
+{{- end }}
+</body>
+</html>
+`))
+
+// adminServer implements the local admin API.
+type adminServer struct {
+	otp     *pendingPrompts
+	captcha *pendingPrompts
+
+	// token is required by all endpoints if set
+	token string
+
+	accounts  []*adminAccount
+	accountMu sync.Mutex
+}
+
+// adminAccount exposes the scheduler of a single account to the admin API.
+type adminAccount struct {
+	ctx       *AppContext
+	sess      *hhSession
+	sched     *resumeScheduler
+	discovery *discoveryTrigger
+}
+
+// adminResumeStatus is a scheduled resume, as reported by the admin API.
+type adminResumeStatus struct {
+	Account string `json:"account"`
+	resumeStatus
+
+	SchedulerPaused bool `json:"scheduler_paused"`
+}
+
+func newAdminServer(otp, captcha *pendingPrompts, token string) *adminServer {
+	return &adminServer{
+		otp:     otp,
+		captcha: captcha,
+		token:   token,
+	}
+}
+
+func (api *adminServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/otp", api.handleOTP)
+	mux.HandleFunc("POST /api/captcha", api.handleCaptchaAnswer)
+
+	// Scheduler control
+	mux.HandleFunc("GET /api/resumes", api.handleListResumes)
+	mux.HandleFunc("POST /api/resumes/{id}/boost", api.handleBoostResume)
+	mux.HandleFunc("POST /api/resumes/{id}/pause", api.handlePauseResume(true))
+	mux.HandleFunc("POST /api/resumes/{id}/resume", api.handlePauseResume(false))
+	mux.HandleFunc("POST /api/scheduler/pause", api.handlePauseScheduler(true))
+	mux.HandleFunc("POST /api/scheduler/resume", api.handlePauseScheduler(false))
+	mux.HandleFunc("POST /api/discover", api.handleDiscover)
+
+	// Human-friendly captcha page
+	mux.HandleFunc("GET /captcha", api.handleCaptchaPage)
+	mux.HandleFunc("POST /captcha", api.handleCaptchaForm)
+	mux.HandleFunc("GET /captcha/image", api.handleCaptchaImage)
+
+	return api.protect(mux)
+}
+
+// protect guards the API against unauthorized and cross-site requests.
+//
+// If a token is configured, every request has to present it, either as a bearer token
+// or as the password of HTTP basic authentication (so that the captcha page can be opened in a browser).
+// Since browsers attach basic credentials to cross-site requests too, state-changing API requests
+// must also have a JSON content type, which web pages cannot send to other sites without a CORS preflight,
+// and the captcha form must be submitted from the same origin.
+func (api *adminServer) protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if api.token != "" && !api.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="hh-resume-auto-boost"`)
+			writeJSONError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				if mediaType != "application/json" {
+					writeJSONError(w, http.StatusUnsupportedMediaType, errors.New("content type must be application/json"))
+					return
+				}
+			} else if !isSameOriginRequest(r) {
+				http.Error(w, "cross-site requests are not allowed", http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorized checks that the request presents the API token.
+func (api *adminServer) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		_, token, ok = r.BasicAuth()
+	}
+
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(api.token)) == 1
+}
+
+// isSameOriginRequest checks that a browser has sent the request from a page of the API itself.
+// Requests that do not come from browsers have neither of the headers and are allowed.
+func isSameOriginRequest(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return false
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// attach makes the scheduler of an account controllable through the API.
+func (api *adminServer) attach(acc *adminAccount) {
+	api.accountMu.Lock()
+	defer api.accountMu.Unlock()
+
+	api.accounts = append(api.accounts, acc)
+}
+
+// selectAccounts returns the account with the specified name,
+// or all accounts if the name is empty.
+func (api *adminServer) selectAccounts(name string) ([]*adminAccount, error) {
+	api.accountMu.Lock()
+	defer api.accountMu.Unlock()
+
+	if name == "" {
+		return slices.Clone(api.accounts), nil
+	}
+
+	for _, acc := range api.accounts {
+		if acc.ctx.Cfg.Name == name {
+			return []*adminAccount{acc}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown account: %q", name)
+}
+
+// findResume returns the account that has the resume scheduled.
+func (api *adminServer) findResume(id string) (*adminAccount, error) {
+	accounts, err := api.selectAccounts("")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, acc := range accounts {
+		if _, err := acc.sched.lookup(id); err == nil {
+			return acc, nil
+		}
+	}
+
+	return nil, errResumeNotScheduled
+}
+
+// handleOTP accepts a one-time code for a pending two-step login.
+func (api *adminServer) handleOTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Login string `json:"login"`
+		Code  string `json:"code"`
+	}
+
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIRequestBodySize)).Decode(&body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("decoding request body: %w", err))
+		return
+	}
+
+	if body.Code == "" {
+		writeJSONError(w, http.StatusBadRequest, errors.New("missing one-time code"))
+		return
+	}
+
+	err = api.otp.submit(body.Login, body.Code)
+	if err != nil {
+		writeJSONError(w, http.StatusConflict, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCaptchaAnswer accepts a captcha answer for a pending login.
+func (api *adminServer) handleCaptchaAnswer(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Login  string `json:"login"`
+		Answer string `json:"answer"`
+	}
+
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIRequestBodySize)).Decode(&body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("decoding request body: %w", err))
+		return
+	}
+
+	if body.Answer == "" {
+		writeJSONError(w, http.StatusBadRequest, errors.New("missing captcha answer"))
+		return
+	}
+
+	err = api.captcha.submit(body.Login, body.Answer)
+	if err != nil {
+		writeJSONError(w, http.StatusConflict, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleCaptchaPage shows all pending captchas.
+func (api *adminServer) handleCaptchaPage(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	err := captchaPageTemplate.Execute(w, api.captcha.logins())
+	if err != nil {
+		slog.Debug("failed to render captcha page", "error", err)
+	}
+}
+
+// handleCaptchaForm accepts a captcha answer submitted from the captcha page.
+func (api *adminServer) handleCaptchaForm(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxAPIRequestBodySize)
+
+	answer := r.PostFormValue("answer")
+	if answer == "" {
+		http.Error(w, "missing captcha answer", http.StatusBadRequest)
+		return
+	}
+
+	err := api.captcha.submit(r.PostFormValue("login"), answer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	http.Redirect(w, r, "/captcha", http.StatusSeeOther)
+}
+
+// handleCaptchaImage serves the captcha image of a pending login.
+func (api *adminServer) handleCaptchaImage(w http.ResponseWriter, r *http.Request) {
+	image, ok := api.captcha.image(r.URL.Query().Get("login"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(image))
+	w.Header().Set("Cache-Control", "no-store")
+
+	if _, err := w.Write(image); err != nil {
+		slog.Debug("failed to write captcha image", "error", err)
+	}
+}
+
+// handleListResumes lists the scheduled resumes of all accounts.
+func (api *adminServer) handleListResumes(w http.ResponseWriter, r *http.Request) {
+	accounts, err := api.selectAccounts(r.URL.Query().Get("account"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err)
+		return
+	}
+
+	statuses := []adminResumeStatus{}
+	for _, acc := range accounts {
+		paused := acc.sched.isPaused()
+
+		for _, status := range acc.sched.list() {
+			statuses = append(statuses, adminResumeStatus{
+				Account:         acc.ctx.Cfg.Name,
+				resumeStatus:    status,
+				SchedulerPaused: paused,
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+// handleBoostResume boosts a resume immediately.
+func (api *adminServer) handleBoostResume(w http.ResponseWriter, r *http.Request) {
+	acc, err := api.findResume(r.PathValue("id"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err)
+		return
+	}
+
+	err = acc.sched.boostNow(acc.ctx, acc.sess, r.PathValue("id"))
+	switch {
+	case errors.Is(err, errResumeNotScheduled):
+		writeJSONError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrBoostTooEarly):
+		writeJSONError(w, http.StatusConflict, err)
+	case errors.Is(err, errCircuitOpen):
+		writeJSONError(w, http.StatusServiceUnavailable, err)
+	case err != nil:
+		writeJSONError(w, http.StatusBadGateway, err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handlePauseResume returns a handler that pauses or resumes the boosts of a single resume.
+func (api *adminServer) handlePauseResume(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		acc, err := api.findResume(r.PathValue("id"))
+		if err == nil {
+			err = acc.sched.setResumePaused(r.PathValue("id"), paused)
+		}
+
+		if err != nil {
+			writeJSONError(w, http.StatusNotFound, err)
+			return
+		}
+
+		acc.ctx.Log.Info("changed resume pause state through the admin API", "id", r.PathValue("id"), "paused", paused)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handlePauseScheduler returns a handler that pauses or resumes all boosts
+// of the specified account (or of all accounts).
+func (api *adminServer) handlePauseScheduler(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accounts, err := api.selectAccounts(r.URL.Query().Get("account"))
+		if err != nil {
+			writeJSONError(w, http.StatusNotFound, err)
+			return
+		}
+
+		for _, acc := range accounts {
+			acc.sched.setPaused(paused)
+			acc.ctx.Log.Info("changed scheduler pause state through the admin API", "paused", paused)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleDiscover forces an immediate resume discovery
+// for the specified account (or for all accounts).
+func (api *adminServer) handleDiscover(w http.ResponseWriter, r *http.Request) {
+	accounts, err := api.selectAccounts(r.URL.Query().Get("account"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err)
+		return
+	}
+
+	errs := make([]error, 0, len(accounts))
+	for _, acc := range accounts {
+		if err := acc.discovery.trigger(); err != nil {
+			errs = append(errs, fmt.Errorf("account %q: %w", acc.ctx.Cfg.Name, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		writeJSONError(w, http.StatusConflict, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
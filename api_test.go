@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestAdminSchedulerControl checks the scheduler control endpoints of the admin API.
+func TestAdminSchedulerControl(t *testing.T) {
+	srv, _ := newFakeHHServer(t)
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Cfg.Name = "test"
+	ctx.Context = t.Context()
+
+	state, err := loadStateStore("")
+	if err != nil {
+		t.Fatalf("loading state: %v", err)
+	}
+
+	sess := newHHSession(createHTTPClient(ctx), nil, nil)
+	sched := newResumeScheduler(state, nil, nil, nil)
+	defer sched.teardown()
+
+	lastBoost := time.Now().Add(-time.Hour)
+	sched.reconcile(ctx, sess, []*hhResume{{id: "abc", title: "test", lastBoost: lastBoost}})
+
+	api := newAdminServer(newPendingPrompts(), newPendingPrompts(), "")
+	api.attach(&adminAccount{
+		ctx:       ctx,
+		sess:      sess,
+		sched:     sched,
+		discovery: newDiscoveryTrigger(),
+	})
+
+	call := func(method, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequestWithContext(t.Context(), method, path, nil)
+		r.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		api.handler().ServeHTTP(rec, r)
+		return rec
+	}
+
+	list := func() []adminResumeStatus {
+		rec := call(http.MethodGet, "/api/resumes")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("invalid status code: got %v, expected %v", rec.Code, http.StatusOK)
+		}
+
+		var statuses []adminResumeStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil {
+			t.Fatalf("decoding resume list: %v", err)
+		}
+
+		return statuses
+	}
+
+	// Wait until the boost goroutine computes the next boost time
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		statuses := list()
+		if len(statuses) == 1 && !statuses[0].NextBoost.IsZero() {
+			if statuses[0].Account != "test" || statuses[0].ID != "abc" {
+				t.Errorf("invalid resume status: %+v", statuses[0])
+			}
+
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("resume has not been scheduled: %+v", statuses)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodPost, "/api/resumes/abc/boost", http.StatusNoContent},
+		{http.MethodPost, "/api/resumes/missing/boost", http.StatusNotFound},
+		{http.MethodPost, "/api/resumes/abc/pause", http.StatusNoContent},
+		{http.MethodPost, "/api/resumes/missing/pause", http.StatusNotFound},
+		{http.MethodPost, "/api/scheduler/pause", http.StatusNoContent},
+		{http.MethodPost, "/api/scheduler/pause?account=missing", http.StatusNotFound},
+		{http.MethodPost, "/api/discover", http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if rec := call(tt.method, tt.path); rec.Code != tt.code {
+				t.Errorf("invalid status code: got %v, expected %v (%v)", rec.Code, tt.code, rec.Body.String())
+			}
+		})
+	}
+
+	statuses := list()
+	if len(statuses) != 1 {
+		t.Fatalf("invalid number of resumes: got %v, expected 1", len(statuses))
+	}
+
+	if !statuses[0].LastBoost.After(lastBoost) {
+		t.Errorf("manual boost has not been recorded: %+v", statuses[0])
+	}
+
+	if !statuses[0].Paused || !statuses[0].SchedulerPaused {
+		t.Errorf("invalid pause state: %+v", statuses[0])
+	}
+}
+
+// TestAdminAPIProtection checks that the admin API rejects unauthorized and cross-site requests.
+func TestAdminAPIProtection(t *testing.T) {
+	api := newAdminServer(newPendingPrompts(), newPendingPrompts(), "secret")
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		code    int
+	}{
+		{"missing token", http.MethodGet, "/api/resumes", nil, http.StatusUnauthorized},
+		{"invalid token", http.MethodGet, "/api/resumes", map[string]string{"Authorization": "Bearer wrong"}, http.StatusUnauthorized},
+		{"bearer token", http.MethodGet, "/api/resumes", map[string]string{"Authorization": "Bearer secret"}, http.StatusOK},
+		{"basic auth", http.MethodGet, "/captcha", map[string]string{"Authorization": "Basic dXNlcjpzZWNyZXQ="}, http.StatusOK},
+		{
+			"simple content type", http.MethodPost, "/api/scheduler/pause",
+			map[string]string{"Authorization": "Bearer secret", "Content-Type": "text/plain"},
+			http.StatusUnsupportedMediaType,
+		},
+		{
+			"json content type", http.MethodPost, "/api/scheduler/pause",
+			map[string]string{"Authorization": "Bearer secret", "Content-Type": "application/json"},
+			http.StatusNoContent,
+		},
+		{
+			"cross-site captcha form", http.MethodPost, "/captcha",
+			map[string]string{"Authorization": "Basic dXNlcjpzZWNyZXQ=", "Origin": "https://evil.example.com", "Sec-Fetch-Site": "cross-site"},
+			http.StatusForbidden,
+		},
+		{
+			"same-origin captcha form", http.MethodPost, "/captcha",
+			map[string]string{"Authorization": "Basic dXNlcjpzZWNyZXQ=", "Origin": "http://example.com", "Sec-Fetch-Site": "same-origin"},
+			http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequestWithContext(t.Context(), tt.method, tt.path, nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+
+			rec := httptest.NewRecorder()
+			api.handler().ServeHTTP(rec, r)
+
+			if rec.Code != tt.code {
+				t.Errorf("invalid status code: got %v, expected %v (%v)", rec.Code, tt.code, rec.Body.String())
+			}
+		})
+	}
+}
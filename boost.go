@@ -3,57 +3,197 @@ package main
 import (
 	"errors"
 	"fmt"
-	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/imroc/req/v3"
 )
 
 var ErrBoostTooEarly = errors.New("resume cannot be boosted yet (too early)")
 
-// hhBoostResume boosts a single resume using the HeadHunter API.
-func hhBoostResume(ctx *AppContext, cl *req.Client, resume *hhResume) error {
-	slog.Debug("boosting resume", "title", resume.title)
-
-	r := cl.R()
-	r.SetHeaders(map[string]string{
-		"Sec-Fetch-Dest":   "empty",
-		"Sec-Fetch-Mode":   "cors",
-		"Sec-Fetch-Site":   "same-origin",
-		"X-Requested-With": "XMLHTTPRequest",
-		"X-Xsrftoken":      resume.xsrf,
-		"Accept":           "application/json",
-		"Referer":          buildHHURL(ctx, "/applicant/resumes?role=applicant"),
-	})
+// boostErrorClass describes the kind of a boost failure.
+// Every class has its own retry policy.
+type boostErrorClass string
 
-	r.EnableForceMultipart()
+const (
+	boostErrorTooEarly     boostErrorClass = "too_early"
+	boostErrorAuthExpired  boostErrorClass = "auth_expired"
+	boostErrorNotFound     boostErrorClass = "not_found"
+	boostErrorRateLimited  boostErrorClass = "rate_limited"
+	boostErrorServerError  boostErrorClass = "server_error"
+	boostErrorNetworkError boostErrorClass = "network_error"
+)
 
-	r.SetFormData(map[string]string{
-		"resume":       resume.id,
-		"undirectable": "true",
-	})
+// boostErrorClasses lists all boost error classes.
+var boostErrorClasses = []boostErrorClass{
+	boostErrorTooEarly,
+	boostErrorAuthExpired,
+	boostErrorNotFound,
+	boostErrorRateLimited,
+	boostErrorServerError,
+	boostErrorNetworkError,
+}
+
+// boostError is returned by hhBoostResume if the boost has failed.
+type boostError struct {
+	class boostErrorClass
+
+	// statusCode is zero if no response has been received
+	statusCode int
+
+	// retryAfter is the delay requested by HH in the Retry-After header; zero if there is none
+	retryAfter time.Duration
+
+	err error
+}
+
+func (e *boostError) Error() string {
+	return e.err.Error()
+}
+
+func (e *boostError) Unwrap() error {
+	return e.err
+}
 
-	setGSSHeaders(cl, r)
+// classifyBoostResponse builds a boostError from a failed response.
+// Unexpected status codes are treated as server errors.
+func classifyBoostResponse(resp *req.Response, now time.Time) *boostError {
+	be := &boostError{
+		class:      boostErrorServerError,
+		statusCode: resp.StatusCode,
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), now),
+		err:        fmt.Errorf("received an HTTP error: status code %v", resp.StatusCode),
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusConflict:
+		be.class = boostErrorTooEarly
+		be.err = ErrBoostTooEarly
+	case isSessionExpired(resp):
+		be.class = boostErrorAuthExpired
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		be.class = boostErrorNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		be.class = boostErrorRateLimited
+	}
+
+	return be
+}
+
+// classifyBoostError returns the class of a boost failure.
+// Errors that have not come from HH (which should not normally happen) are treated as server errors.
+func classifyBoostError(err error) (boostErrorClass, time.Duration) {
+	var be *boostError
+	if errors.As(err, &be) {
+		return be.class, be.retryAfter
+	}
+
+	return boostErrorServerError, 0
+}
+
+// boostRetryDelay returns the delay before the next boost attempt
+// after the specified number of consecutive failures.
+// The delay doubles after every failure up to the maximum one,
+// unless HH has asked us to wait even longer.
+func boostRetryDelay(policy BoostRetryPolicy, failures int, retryAfter time.Duration) time.Duration {
+	delay := policy.InitialDelay
+	for i := 1; i < failures && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return max(min(delay, policy.MaxDelay), retryAfter)
+}
+
+// parseRetryAfter parses the value of the Retry-After header,
+// which is either a number of seconds or an HTTP date.
+// It returns zero if the value is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+
+	return 0
+}
+
+// hhBoostResume boosts a single resume using the HeadHunter API
+// and notifies the user about the outcome.
+// A boost that has not been attempted because of the circuit breaker is not reported:
+// the boost is retried as soon as the breaker lets the requests through.
+func hhBoostResume(ctx *AppContext, sess *hhSession, resume *hhResume) error {
+	err := hhTouchResume(ctx, sess, resume)
+	if errors.Is(err, errCircuitOpen) {
+		return err
+	}
 
-	resp, err := r.Post(buildHHURL(ctx, "/applicant/resumes/touch"))
 	if err != nil {
-		return fmt.Errorf("sending HTTP request: %w", err)
+		ctx.Notifier.publish(ctx, event{
+			Kind:        eventBoostFailed,
+			ResumeID:    resume.id,
+			ResumeTitle: resume.title,
+			Error:       err.Error(),
+		})
+
+		return err
+	}
+
+	ctx.Notifier.publish(ctx, event{
+		Kind:        eventBoostSucceeded,
+		ResumeID:    resume.id,
+		ResumeTitle: resume.title,
+	})
+
+	return nil
+}
+
+// hhTouchResume sends the actual boost request.
+func hhTouchResume(ctx *AppContext, sess *hhSession, resume *hhResume) error {
+	ctx.Log.Debug("boosting resume", "title", resume.title)
+
+	resp, err := sess.do(ctx, http.MethodPost, "/applicant/resumes/touch", func(r *req.Request, xsrf string) {
+		r.SetHeaders(map[string]string{
+			"Sec-Fetch-Dest":   "empty",
+			"Sec-Fetch-Mode":   "cors",
+			"Sec-Fetch-Site":   "same-origin",
+			"X-Requested-With": "XMLHTTPRequest",
+			"X-Xsrftoken":      xsrf,
+			"Accept":           "application/json",
+			"Referer":          buildHHURL(ctx, "/applicant/resumes?role=applicant"),
+		})
+
+		r.EnableForceMultipart()
+
+		r.SetFormData(map[string]string{
+			"resume":       resume.id,
+			"undirectable": "true",
+		})
+
+		setGSSHeaders(sess.cl, r)
+	})
+	if errors.Is(err, errAuthenticationFailed) {
+		return &boostError{class: boostErrorAuthExpired, err: err}
+	} else if err != nil {
+		return &boostError{class: boostErrorNetworkError, err: err}
 	}
 
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			slog.Error("failed to close response body", "error", err)
+			ctx.Log.Error("failed to close response body", "error", err)
 		}
 	}()
 
-	if resp.StatusCode == http.StatusConflict {
-		return ErrBoostTooEarly
-	}
-
 	if !resp.IsSuccessState() {
-		return fmt.Errorf("received an HTTP error: status code %v", resp.StatusCode)
+		return classifyBoostResponse(resp, time.Now())
 	}
 
-	slog.Info("boosted resume", "title", resume.title)
+	ctx.Log.Info("boosted resume", "title", resume.title)
 	return nil
 }
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestBoostErrorClassification checks that boost failures are classified by the HH response.
+func TestBoostErrorClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		class      boostErrorClass
+		wait       time.Duration
+	}{
+		{"too early", http.StatusConflict, "", boostErrorTooEarly, 0},
+		{"not found", http.StatusNotFound, "", boostErrorNotFound, 0},
+		{"rate limited", http.StatusTooManyRequests, "120", boostErrorRateLimited, 2 * time.Minute},
+		{"server error", http.StatusServiceUnavailable, "", boostErrorServerError, 0},
+		{"unexpected status", http.StatusBadRequest, "", boostErrorServerError, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if test.retryAfter != "" {
+					w.Header().Set("Retry-After", test.retryAfter)
+				}
+
+				w.WriteHeader(test.status)
+			}))
+			t.Cleanup(srv.Close)
+
+			ctx := newTestAppContext(t, srv.URL)
+			sess := newHHSession(createHTTPClient(ctx), nil, nil)
+
+			err := hhBoostResume(ctx, sess, &hhResume{id: "abc"})
+
+			class, wait := classifyBoostError(err)
+			if class != test.class {
+				t.Errorf("invalid error class: got %v, expected %v", class, test.class)
+			}
+
+			if wait != test.wait {
+				t.Errorf("invalid retry delay: got %v, expected %v", wait, test.wait)
+			}
+
+			if errors.Is(err, ErrBoostTooEarly) != (test.class == boostErrorTooEarly) {
+				t.Errorf("invalid too early error: %v", err)
+			}
+		})
+	}
+
+	t.Run("network error", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		srv.Close()
+
+		ctx := newTestAppContext(t, srv.URL)
+		sess := newHHSession(createHTTPClient(ctx), nil, nil)
+
+		class, _ := classifyBoostError(hhBoostResume(ctx, sess, &hhResume{id: "abc"}))
+		if class != boostErrorNetworkError {
+			t.Errorf("invalid error class: got %v, expected %v", class, boostErrorNetworkError)
+		}
+	})
+
+	t.Run("auth expired", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		t.Cleanup(srv.Close)
+
+		ctx := newTestAppContext(t, srv.URL)
+		sess := newHHSession(createHTTPClient(ctx), nil, nil)
+
+		class, _ := classifyBoostError(hhBoostResume(ctx, sess, &hhResume{id: "abc"}))
+		if class != boostErrorAuthExpired {
+			t.Errorf("invalid error class: got %v, expected %v", class, boostErrorAuthExpired)
+		}
+	})
+}
+
+// TestParseRetryAfter checks that both forms of the Retry-After header are supported.
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"-5", 0},
+		{"Thu, 01 Jan 2026 12:10:00 GMT", 10 * time.Minute},
+		{"Thu, 01 Jan 2026 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	for _, test := range tests {
+		if d := parseRetryAfter(test.value, now); d != test.expected {
+			t.Errorf("invalid delay for %q: got %v, expected %v", test.value, d, test.expected)
+		}
+	}
+}
+
+// TestBoostRetryDelay checks that the retry delay grows exponentially up to the cap.
+func TestBoostRetryDelay(t *testing.T) {
+	policy := BoostRetryPolicy{
+		InitialDelay: time.Minute,
+		MaxDelay:     10 * time.Minute,
+	}
+
+	tests := []struct {
+		failures   int
+		retryAfter time.Duration
+		expected   time.Duration
+	}{
+		{1, 0, time.Minute},
+		{2, 0, 2 * time.Minute},
+		{4, 0, 8 * time.Minute},
+		{5, 0, 10 * time.Minute},
+		{100, 0, 10 * time.Minute},
+		{1, 5 * time.Minute, 5 * time.Minute},
+		{5, time.Hour, time.Hour},
+	}
+
+	for _, test := range tests {
+		if d := boostRetryDelay(policy, test.failures, test.retryAfter); d != test.expected {
+			t.Errorf("invalid delay after %v failures: got %v, expected %v", test.failures, d, test.expected)
+		}
+	}
+}
+
+// eventRecorder is a notifier that forwards the events to a channel.
+type eventRecorder struct {
+	events chan *event
+}
+
+func (r *eventRecorder) name() string {
+	return "recorder"
+}
+
+func (r *eventRecorder) notify(_ context.Context, ev *event) error {
+	r.events <- ev
+	return nil
+}
+
+// TestBoostSuspension checks that a resume is suspended after too many failed boosts.
+func TestBoostSuspension(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Context = t.Context()
+	ctx.Cfg.BoostRetry.NotFound = BoostRetryPolicy{
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     10 * time.Millisecond,
+		MaxAttempts:  3,
+	}
+
+	recorder := &eventRecorder{events: make(chan *event, notificationQueueSize)}
+	ctx.Notifier.add(recorder, []eventKind{eventBoostSuspended})
+	ctx.Notifier.run(ctx)
+
+	sess := newHHSession(createHTTPClient(ctx), nil, nil)
+	state, err := loadStateStore("")
+	if err != nil {
+		t.Fatalf("loading state: %v", err)
+	}
+
+	sched := newResumeScheduler(state, nil, nil, nil)
+	sched.reconcile(ctx, sess, []*hhResume{{id: "abc", title: "test"}})
+
+	select {
+	case ev := <-recorder.events:
+		if ev.ResumeID != "abc" || ev.Failures != 3 {
+			t.Errorf("invalid suspension event: %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("resume has not been suspended")
+	}
+
+	statuses := sched.list()
+	if len(statuses) != 1 || !statuses[0].Suspended {
+		t.Fatalf("invalid resume status: %+v", statuses)
+	}
+
+	if rs, _ := state.get("abc"); rs.Failures != 3 {
+		t.Errorf("invalid number of failures: got %v, expected 3", rs.Failures)
+	}
+
+	// Resuming lifts the suspension; the boost goroutine is stopped,
+	// so that it does not get suspended again in the meantime
+	sched.teardown()
+
+	if err := sched.setResumePaused("abc", false); err != nil {
+		t.Fatalf("resuming resume: %v", err)
+	}
+
+	if statuses := sched.list(); statuses[0].Suspended {
+		t.Error("resume is still suspended")
+	}
+}
+
+// TestBoostCircuitOpenNotification checks that a boost that has been held back by the circuit breaker
+// is not reported as a failure.
+func TestBoostCircuitOpenNotification(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Context = t.Context()
+	ctx.Cfg.CircuitBreaker.Threshold = 1
+	ctx.Cfg.CircuitBreaker.Cooldown = time.Minute
+	ctx.Breaker = newCircuitBreaker(ctx, newFakeClock(time.Now()))
+
+	recorder := &eventRecorder{events: make(chan *event, notificationQueueSize)}
+	ctx.Notifier.add(recorder, []eventKind{eventBoostFailed, eventBoostSucceeded})
+	ctx.Notifier.run(ctx)
+
+	nextEvent := func() *event {
+		t.Helper()
+
+		select {
+		case ev := <-recorder.events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("event has not been delivered")
+			return nil
+		}
+	}
+
+	sess := newHHSession(createHTTPClient(ctx), nil, nil)
+
+	if err := hhBoostResume(ctx, sess, &hhResume{id: "abc"}); err == nil || errors.Is(err, errCircuitOpen) {
+		t.Fatalf("invalid error: %v", err)
+	}
+
+	if ev := nextEvent(); ev.Kind != eventBoostFailed {
+		t.Fatalf("invalid event: got %v, expected %v", ev.Kind, eventBoostFailed)
+	}
+
+	if err := hhBoostResume(ctx, sess, &hhResume{id: "abc"}); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("invalid error: got %v, expected %v", err, errCircuitOpen)
+	}
+
+	// The events are delivered in order, so the marker is the next one unless a failure has been published
+	ctx.Notifier.publish(ctx, event{Kind: eventBoostSucceeded, ResumeID: "marker"})
+
+	if ev := nextEvent(); ev.ResumeID != "marker" {
+		t.Errorf("invalid event: got %v, expected the marker", ev.Kind)
+	}
+}
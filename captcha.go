@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+const (
+	captchaModeFile = "file"
+	captchaModeWeb  = "web"
+)
+
+// maxCaptchaAttempts limits how many captchas we try to solve during a single login.
+const maxCaptchaAttempts = 3
+
+// hhCaptcha is a captcha that has been solved by a human.
+type hhCaptcha struct {
+	key    string
+	answer string
+}
+
+// hhSolveCaptcha fetches a new HHCaptcha image, hands it off to a human and waits for the answer.
+// The image is also attached to the captcha_required event, so that it can be solved from a notification.
+func hhSolveCaptcha(ctx *AppContext, sess *hhSession, xsrf string) (*hhCaptcha, error) {
+	ctx.Log.Info("HH requires a captcha, fetching the captcha image")
+
+	key, image, err := hhFetchCaptcha(ctx, sess, xsrf)
+	ctx.Notifier.publish(ctx, event{Kind: eventCaptchaRequired, Image: image})
+	if err != nil {
+		return nil, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, ctx.Cfg.Captcha.Timeout)
+	defer cancel()
+
+	answer, err := sess.captcha.answer(waitCtx, ctx.Cfg.Login, image)
+	if err != nil {
+		return nil, fmt.Errorf("obtaining captcha answer: %w", err)
+	}
+
+	return &hhCaptcha{
+		key:    key,
+		answer: answer,
+	}, nil
+}
+
+// hhFetchCaptcha requests a new HHCaptcha and returns its key and image.
+func hhFetchCaptcha(ctx *AppContext, sess *hhSession, xsrf string) (string, []byte, error) {
+	r := sess.cl.R()
+	setLoginHeaders(ctx, r, xsrf)
+	setGSSHeaders(sess.cl, r)
+
+	resp, err := r.Post(buildHHURL(ctx, "/captcha?lang=RU"))
+	if err != nil {
+		return "", nil, fmt.Errorf("sending HTTP request: %w", err)
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			ctx.Log.Error("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	if !resp.IsSuccessState() {
+		return "", nil, fmt.Errorf("received an HTTP error while requesting a captcha: status code %v", resp.StatusCode)
+	}
+
+	var captchaResp struct {
+		Key string `json:"key"`
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&captchaResp)
+	if err != nil {
+		return "", nil, fmt.Errorf("decoding captcha response: %w", err)
+	}
+
+	if captchaResp.Key == "" {
+		return "", nil, errors.New("missing captcha key")
+	}
+
+	image, err := hhFetchCaptchaImage(ctx, sess, captchaResp.Key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return captchaResp.Key, image, nil
+}
+
+// hhFetchCaptchaImage downloads the captcha picture.
+func hhFetchCaptchaImage(ctx *AppContext, sess *hhSession, key string) ([]byte, error) {
+	r := sess.cl.R()
+	r.SetHeaders(map[string]string{
+		"Sec-Fetch-Dest": "image",
+		"Sec-Fetch-Mode": "no-cors",
+		"Sec-Fetch-Site": "same-origin",
+		"Accept":         "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8",
+		"Referer":        buildHHURL(ctx, "/account/login?backurl=%2Fapplicant%2Fresumes&role=applicant"),
+	})
+
+	resp, err := r.Get(buildHHURL(ctx, "/captcha/picture?key="+url.QueryEscape(key)))
+	if err != nil {
+		return nil, fmt.Errorf("sending HTTP request: %w", err)
+	}
+
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			ctx.Log.Error("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	if !resp.IsSuccessState() {
+		return nil, fmt.Errorf("received an HTTP error while fetching captcha image: status code %v", resp.StatusCode)
+	}
+
+	image, err := resp.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("reading captcha image: %w", err)
+	}
+
+	return image, nil
+}
+
+// newCaptchaSolver creates the captcha hand-off according to the config.
+// It returns nil if captcha hand-off is disabled.
+func newCaptchaSolver(ctx *AppContext, prompts *sharedPrompts) answerSource {
+	switch ctx.Cfg.Captcha.Mode {
+	case captchaModeFile:
+		return newFilePrompt(ctx.Cfg.Captcha.AnswerFileName, ctx.Cfg.Captcha.ImageFileName, ctx.Cfg.Captcha.PollInterval)
+	case captchaModeWeb:
+		return prompts.captcha
+	}
+
+	return nil
+}
+
+// setCaptchaFields fills the captcha fields of a login form.
+// If captcha is nil, the fields are left empty, just like the HH frontend does.
+func setCaptchaFields(form map[string]string, captcha *hhCaptcha) map[string]string {
+	if captcha == nil {
+		form["captchaText"] = ""
+		return form
+	}
+
+	form["captchaKey"] = captcha.key
+	form["captchaText"] = captcha.answer
+	return form
+}
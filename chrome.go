@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A known stable Chrome release, from which the current version is estimated.
+const chromeAnchorVersion = 147
+
+var chromeAnchorDate = time.Date(2026, time.April, 7, 0, 0, 0, 0, time.UTC)
+
+// chromeReleaseInterval is the average time between the stable Chrome releases.
+// Chrome ships a new major version every 4 weeks, with a longer gap over the winter holidays,
+// which averages to about a month. Rounding up means that the estimate lags behind rather than
+// running ahead of the actual releases, since a version that has not been released yet is a giveaway.
+const chromeReleaseInterval = 31 * 24 * time.Hour
+
+// chromeVersionReloadInterval specifies how often the Chrome version file is checked for changes.
+const chromeVersionReloadInterval = 5 * time.Minute
+
+// chromeVersionMaxLag is the number of major versions by which the configured Chrome version
+// may fall behind the estimated one before we start warning about it.
+const chromeVersionMaxLag = 3
+
+// estimateChromeVersion returns a plausible major version of the stable Chrome at the specified time.
+func estimateChromeVersion(now time.Time) int {
+	elapsed := now.Sub(chromeAnchorDate)
+	if elapsed < 0 {
+		return chromeAnchorVersion
+	}
+
+	return chromeAnchorVersion + int(elapsed/chromeReleaseInterval)
+}
+
+// chromeVersionFile is the JSON file that the Chrome version can be read from.
+// Both {"version": "147.0.7727.55"} and the format of Chrome for Testing
+// (last-known-good-versions.json) are accepted.
+type chromeVersionFile struct {
+	Version  string `json:"version"`
+	Channels struct {
+		Stable struct {
+			Version string `json:"version"`
+		} `json:"Stable"`
+	} `json:"channels"`
+}
+
+// loadChromeVersionFile reads the major Chrome version from a JSON file.
+func loadChromeVersionFile(fileName string) (int, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return 0, fmt.Errorf("reading Chrome version file: %w", err)
+	}
+
+	var f chromeVersionFile
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return 0, fmt.Errorf("decoding Chrome version file: %w", err)
+	}
+
+	version := f.Version
+	if version == "" {
+		version = f.Channels.Stable.Version
+	}
+
+	if version == "" {
+		return 0, errors.New("missing version in Chrome version file")
+	}
+
+	major, _, _ := strings.Cut(version, ".")
+	v, err := strconv.Atoi(major)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid Chrome version: %q", version)
+	}
+
+	return v, nil
+}
+
+// resolveChromeVersion determines the Chrome version to impersonate:
+// the version file takes priority over chrome_version, which defaults to the estimated version.
+// It warns if the resulting version seems to be outdated.
+func resolveChromeVersion(ctx *AppContext, now time.Time) int {
+	version := ctx.Cfg.ChromeVersion
+
+	if ctx.Cfg.ChromeVersionFile != "" {
+		v, err := loadChromeVersionFile(ctx.Cfg.ChromeVersionFile)
+		if err != nil {
+			ctx.Log.Warn("failed to load Chrome version file, falling back to the configured version",
+				"error", err, "version", version)
+		} else {
+			version = v
+		}
+	}
+
+	warnOutdatedChromeVersion(ctx, version, now)
+	return version
+}
+
+// warnOutdatedChromeVersion warns if the version falls too far behind the estimated one.
+func warnOutdatedChromeVersion(ctx *AppContext, version int, now time.Time) {
+	estimated := estimateChromeVersion(now)
+	if estimated-version > chromeVersionMaxLag {
+		ctx.Log.Warn("impersonated Chrome version appears to be outdated", "version", version, "estimated", estimated)
+	}
+}
+
+// chromeVersionSource keeps the impersonated Chrome version up to date with the Chrome version file,
+// so that the file can be refreshed without restarting the tool.
+type chromeVersionSource struct {
+	fileName string
+	clock    clock
+
+	// These are guarded by mu
+	mu      sync.Mutex
+	version int
+	modTime time.Time
+}
+
+// newChromeVersionSource creates a source that starts with the already resolved version.
+// If there is no Chrome version file, it returns nil, since the version never changes.
+func newChromeVersionSource(ctx *AppContext, clk clock) *chromeVersionSource {
+	if ctx.Cfg.ChromeVersionFile == "" {
+		return nil
+	}
+
+	return &chromeVersionSource{
+		fileName: ctx.Cfg.ChromeVersionFile,
+		clock:    clk,
+		version:  ctx.Cfg.ChromeVersion,
+	}
+}
+
+// current returns the Chrome version to impersonate.
+func (cs *chromeVersionSource) current() int {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	return cs.version
+}
+
+// reload re-reads the Chrome version file if it has been modified since the last load,
+// and reports whether the version has changed.
+// If the file cannot be read, the current version is kept.
+func (cs *chromeVersionSource) reload() (bool, error) {
+	info, err := os.Stat(cs.fileName)
+	if err != nil {
+		return false, fmt.Errorf("reading Chrome version file: %w", err)
+	}
+
+	cs.mu.Lock()
+	unchanged := cs.modTime.Equal(info.ModTime())
+	cs.mu.Unlock()
+
+	if unchanged {
+		return false, nil
+	}
+
+	version, err := loadChromeVersionFile(cs.fileName)
+	if err != nil {
+		return false, err
+	}
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	changed := cs.version != version
+	cs.version = version
+	cs.modTime = info.ModTime()
+
+	return changed, nil
+}
+
+// run periodically reloads the Chrome version file until the context is cancelled.
+func (cs *chromeVersionSource) run(ctx *AppContext) {
+	for {
+		select {
+		case <-cs.clock.After(chromeVersionReloadInterval):
+		case <-ctx.Done():
+			return
+		}
+
+		changed, err := cs.reload()
+		if err != nil {
+			ctx.Log.Error("failed to reload Chrome version file, keeping the current version", "error", err)
+			continue
+		}
+
+		if changed {
+			version := cs.current()
+			ctx.Log.Info("impersonated Chrome version has changed", "version", version)
+			warnOutdatedChromeVersion(ctx, version, cs.clock.Now())
+		}
+	}
+}
+
+// currentChromeVersion returns the Chrome version that the account impersonates at the moment.
+func currentChromeVersion(ctx *AppContext) int {
+	if ctx.Chrome == nil {
+		return ctx.Cfg.ChromeVersion
+	}
+
+	return ctx.Chrome.current()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEstimateChromeVersion checks that the Chrome version follows the release cadence.
+func TestEstimateChromeVersion(t *testing.T) {
+	tests := []struct {
+		now      time.Time
+		expected int
+	}{
+		{chromeAnchorDate.AddDate(-1, 0, 0), chromeAnchorVersion},
+		{chromeAnchorDate, chromeAnchorVersion},
+		{chromeAnchorDate.AddDate(0, 0, 30), chromeAnchorVersion},
+		{chromeAnchorDate.AddDate(0, 0, 31), chromeAnchorVersion + 1},
+		{time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC), 153},
+		{time.Date(2027, time.April, 7, 0, 0, 0, 0, time.UTC), 158},
+	}
+
+	for _, test := range tests {
+		if v := estimateChromeVersion(test.now); v != test.expected {
+			t.Errorf("invalid Chrome version at %v: got %v, expected %v", test.now.Format(time.DateOnly), v, test.expected)
+		}
+	}
+}
+
+// TestLoadChromeVersionFile checks the supported formats of the Chrome version file.
+func TestLoadChromeVersionFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected int
+	}{
+		{"plain", `{"version": "150.0.7800.12"}`, 150},
+		{"chrome for testing", `{"timestamp": "2026-06-01T00:00:00.000Z", "channels": {"Stable": {"channel": "Stable", "version": "149.0.7790.3"}, "Beta": {"channel": "Beta", "version": "150.0.7800.12"}}}`, 149},
+		{"major only", `{"version": "151"}`, 151},
+		{"missing version", `{"channels": {}}`, 0},
+		{"invalid version", `{"version": "latest"}`, 0},
+		{"invalid json", `version: 150`, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "chrome.json")
+			if err := os.WriteFile(fileName, []byte(test.content), 0o600); err != nil {
+				t.Fatalf("writing file: %v", err)
+			}
+
+			v, err := loadChromeVersionFile(fileName)
+			if test.expected == 0 {
+				if err == nil {
+					t.Fatalf("expected an error but got version %v", v)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("loading Chrome version file: %v", err)
+			}
+
+			if v != test.expected {
+				t.Errorf("invalid Chrome version: got %v, expected %v", v, test.expected)
+			}
+		})
+	}
+}
+
+// TestResolveChromeVersion checks the priority of the Chrome version sources and the staleness warning.
+func TestResolveChromeVersion(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+
+	fileName := filepath.Join(t.TempDir(), "chrome.json")
+	if err := os.WriteFile(fileName, []byte(`{"version": "152.0.7900.1"}`), 0o600); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		version   int
+		fileName  string
+		expected  int
+		outdated  bool
+		fileError bool
+	}{
+		{"configured", 151, "", 151, false, false},
+		{"outdated", 140, "", 140, true, false},
+		{"file", 140, fileName, 152, false, false},
+		{"missing file", 150, fileName + ".missing", 150, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+
+			ctx := newTestAppContext(t, "http://hh.invalid")
+			ctx.Log = slog.New(slog.NewTextHandler(buf, nil))
+			ctx.Cfg.ChromeVersion = test.version
+			ctx.Cfg.ChromeVersionFile = test.fileName
+
+			if v := resolveChromeVersion(ctx, now); v != test.expected {
+				t.Errorf("invalid Chrome version: got %v, expected %v", v, test.expected)
+			}
+
+			if outdated := strings.Contains(buf.String(), "outdated"); outdated != test.outdated {
+				t.Errorf("invalid outdated warning: got %v, expected %v", outdated, test.outdated)
+			}
+
+			if fileError := strings.Contains(buf.String(), "failed to load"); fileError != test.fileError {
+				t.Errorf("invalid file error warning: got %v, expected %v", fileError, test.fileError)
+			}
+		})
+	}
+}
+
+// TestChromeVersionReload checks that a change of the Chrome version file is picked up at runtime,
+// and that the User-Agent and the client hints follow it.
+func TestChromeVersionReload(t *testing.T) {
+	srv, lastHeaders := newClientHintsServer(t, "")
+	dir := t.TempDir()
+
+	fileName := filepath.Join(dir, "chrome.json")
+	if err := os.WriteFile(fileName, []byte(`{"version": "150.0.7800.12"}`), 0o600); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Context = t.Context()
+	ctx.Cfg.CookieJarFileName = filepath.Join(dir, "cookies.json")
+	ctx.Cfg.ChromeVersionFile = fileName
+	ctx.Cfg.ChromeVersion = resolveChromeVersion(ctx, time.Now())
+
+	clk := newFakeClock(time.Now())
+	ctx.Chrome = newChromeVersionSource(ctx, clk)
+	go ctx.Chrome.run(ctx)
+
+	cl := createHTTPClient(ctx)
+
+	assertVersion := func(version string) {
+		t.Helper()
+
+		if _, err := cl.R().Get(srv.URL); err != nil {
+			t.Fatalf("sending request: %v", err)
+		}
+
+		headers := lastHeaders()
+		if ua := headers.Get("User-Agent"); !strings.Contains(ua, "Chrome/"+version+".") {
+			t.Errorf("invalid user agent: got %q, expected Chrome %v", ua, version)
+		}
+
+		if brands := headers.Get("Sec-Ch-Ua"); !strings.Contains(brands, `"Google Chrome";v="`+version+`"`) {
+			t.Errorf("invalid brands: got %q, expected Chrome %v", brands, version)
+		}
+	}
+
+	assertVersion("150")
+
+	if err := os.WriteFile(fileName, []byte(`{"version": "151.0.7860.3"}`), 0o600); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+
+	// The reload is detected by the modification time, which may be too coarse to change by itself
+	if err := os.Chtimes(fileName, time.Time{}, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("changing modification time: %v", err)
+	}
+
+	clk.waitForTimers(t)
+	clk.advance(chromeVersionReloadInterval)
+
+	// The next timer is armed once the file has been reloaded
+	clk.waitForTimers(t)
+
+	if v := ctx.Chrome.current(); v != 151 {
+		t.Fatalf("invalid Chrome version: got %v, expected 151", v)
+	}
+
+	assertVersion("151")
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// errCircuitOpen is returned instead of sending a request while HH appears to be down.
+var errCircuitOpen = errors.New("HH appears to be down, requests are paused by the circuit breaker")
+
+type circuitState int
+
+// The values are exported as the circuit breaker state metric.
+const (
+	circuitClosed circuitState = iota
+	circuitHalfOpen
+	circuitOpen
+)
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitClosed:
+		return "closed"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "open"
+	}
+}
+
+// closedCh is a channel that is always ready.
+var closedCh = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// circuitBreaker stops sending requests to HH after too many consecutive network errors or HTTP 5xx responses.
+// Once the cooldown has passed, a single probe request is let through:
+// the breaker closes if it succeeds, and opens for another cooldown otherwise.
+//
+// A nil circuitBreaker never stops the requests.
+type circuitBreaker struct {
+	ctx *AppContext
+	clk clock
+
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    circuitState
+	failures int
+
+	// probing is set while the probe request of a half-open breaker is in flight
+	probing bool
+
+	// readyCh is closed when a request may be attempted,
+	// i.e. when the breaker is closed, or when it is half-open and waits for a probe
+	readyCh chan struct{}
+
+	// gen is incremented whenever the breaker opens, so that stale cooldown timers can be told apart
+	gen uint64
+}
+
+// newCircuitBreaker creates a circuit breaker from the account config.
+// If the circuit breaker is disabled, it returns nil.
+func newCircuitBreaker(ctx *AppContext, clk clock) *circuitBreaker {
+	if ctx.Cfg.CircuitBreaker.Threshold == 0 {
+		return nil
+	}
+
+	ctx.Metrics.circuitBreakerState.WithLabelValues(ctx.Cfg.Name).Set(float64(circuitClosed))
+
+	return &circuitBreaker{
+		ctx:       ctx,
+		clk:       clk,
+		threshold: ctx.Cfg.CircuitBreaker.Threshold,
+		cooldown:  ctx.Cfg.CircuitBreaker.Cooldown,
+		readyCh:   closedCh,
+	}
+}
+
+// instrument makes the client consult the breaker before every request.
+// It must be called after any other instrumentation, so that the rejected requests are not observed.
+func (cb *circuitBreaker) instrument(cl *req.Client) {
+	if cb == nil {
+		return
+	}
+
+	cl.WrapRoundTripFunc(func(rt req.RoundTripper) req.RoundTripFunc {
+		return func(r *req.Request) (*req.Response, error) {
+			probe, err := cb.acquire()
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := rt.RoundTrip(r)
+
+			switch {
+			case errors.Is(err, context.Canceled):
+				// Cancelled requests say nothing about the health of HH
+				cb.release(probe)
+			case err != nil || resp.Response == nil:
+				cb.record(probe, false)
+			default:
+				cb.record(probe, resp.StatusCode < http.StatusInternalServerError)
+			}
+
+			return resp, err
+		}
+	})
+}
+
+// ready returns a channel that is closed when a request may be attempted.
+func (cb *circuitBreaker) ready() <-chan struct{} {
+	if cb == nil {
+		return closedCh
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.readyCh
+}
+
+// acquire checks whether a request may be sent.
+// It reports whether the request is the probe of a half-open breaker.
+func (cb *circuitBreaker) acquire() (bool, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch {
+	case cb.state == circuitClosed:
+		return false, nil
+	case cb.state == circuitHalfOpen && !cb.probing:
+		cb.probing = true
+		cb.readyCh = make(chan struct{})
+
+		cb.ctx.Log.Info("probing HH after the circuit breaker cooldown")
+		return true, nil
+	default:
+		return false, errCircuitOpen
+	}
+}
+
+// release gives up the probe without recording its outcome.
+func (cb *circuitBreaker) release(probe bool) {
+	if !probe {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probing = false
+	close(cb.readyCh)
+}
+
+// record updates the breaker with the outcome of a request.
+func (cb *circuitBreaker) record(probe, succeeded bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	// The requests that have been sent before the breaker opened do not matter anymore
+	if cb.state != circuitClosed && !probe {
+		return
+	}
+
+	if succeeded {
+		cb.failures = 0
+
+		if probe {
+			cb.ctx.Log.Info("HH is available again, closing the circuit breaker")
+			cb.setState(circuitClosed)
+			cb.probing = false
+			close(cb.readyCh)
+		}
+
+		return
+	}
+
+	cb.failures++
+	if probe || cb.failures >= cb.threshold {
+		cb.open()
+	}
+}
+
+// open stops the requests for the cooldown. The caller must hold mu.
+func (cb *circuitBreaker) open() {
+	cb.ctx.Log.Warn("HH appears to be down, pausing requests", "failures", cb.failures, "cooldown", cb.cooldown)
+	cb.ctx.Metrics.circuitBreakerTrips.WithLabelValues(cb.ctx.Cfg.Name).Inc()
+
+	if cb.state == circuitClosed {
+		cb.readyCh = make(chan struct{})
+	}
+
+	cb.setState(circuitOpen)
+	cb.probing = false
+	cb.gen++
+
+	gen := cb.gen
+	after := cb.clk.After(cb.cooldown)
+
+	go func() {
+		select {
+		case <-after:
+		case <-cb.ctx.Done():
+			return
+		}
+
+		cb.mu.Lock()
+		defer cb.mu.Unlock()
+
+		if cb.gen != gen || cb.state != circuitOpen {
+			return
+		}
+
+		cb.setState(circuitHalfOpen)
+		close(cb.readyCh)
+	}()
+}
+
+// setState changes the state and exports it. The caller must hold mu.
+func (cb *circuitBreaker) setState(state circuitState) {
+	cb.state = state
+	cb.ctx.Log.Debug("circuit breaker state has changed", "state", state)
+	cb.ctx.Metrics.circuitBreakerState.WithLabelValues(cb.ctx.Cfg.Name).Set(float64(state))
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreaker checks that the requests are paused while HH is down,
+// and that they are resumed once a probe request succeeds.
+func TestCircuitBreaker(t *testing.T) {
+	status := &atomic.Int32{}
+	status.Store(http.StatusBadGateway)
+
+	requests := &atomic.Int32{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Context = t.Context()
+	ctx.Cfg.Name = "test"
+	ctx.Cfg.CircuitBreaker.Threshold = 2
+	ctx.Cfg.CircuitBreaker.Cooldown = time.Minute
+
+	clk := newFakeClock(time.Now())
+	ctx.Breaker = newCircuitBreaker(ctx, clk)
+	cl := createHTTPClient(ctx)
+
+	send := func() error {
+		t.Helper()
+
+		_, err := cl.R().Get(srv.URL)
+		return err
+	}
+
+	isReady := func() bool {
+		select {
+		case <-ctx.Breaker.ready():
+			return true
+		default:
+			return false
+		}
+	}
+
+	assertState := func(expected circuitState) {
+		t.Helper()
+
+		ctx.Breaker.mu.Lock()
+		state := ctx.Breaker.state
+		ctx.Breaker.mu.Unlock()
+
+		if state != expected {
+			t.Fatalf("invalid circuit breaker state: got %v, expected %v", state, expected)
+		}
+	}
+
+	for range 2 {
+		if err := send(); err != nil {
+			t.Fatalf("sending request: %v", err)
+		}
+	}
+
+	assertState(circuitOpen)
+	if isReady() {
+		t.Fatal("circuit breaker is ready while open")
+	}
+
+	if err := send(); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("invalid error: got %v, expected %v", err, errCircuitOpen)
+	}
+
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("invalid number of requests: got %v, expected 2", n)
+	}
+
+	// A failed probe opens the breaker again
+	clk.waitForTimers(t)
+	clk.advance(time.Minute)
+	<-ctx.Breaker.ready()
+	assertState(circuitHalfOpen)
+
+	if err := send(); err != nil {
+		t.Fatalf("sending probe request: %v", err)
+	}
+
+	assertState(circuitOpen)
+
+	// A successful probe closes the breaker
+	status.Store(http.StatusOK)
+	clk.waitForTimers(t)
+	clk.advance(time.Minute)
+	<-ctx.Breaker.ready()
+
+	if err := send(); err != nil {
+		t.Fatalf("sending probe request: %v", err)
+	}
+
+	assertState(circuitClosed)
+	if !isReady() {
+		t.Error("circuit breaker is not ready while closed")
+	}
+
+	if n := requests.Load(); n != 4 {
+		t.Errorf("invalid number of requests: got %v, expected 4", n)
+	}
+
+	mux := http.NewServeMux()
+	ctx.Metrics.register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		`hh_resume_auto_boost_circuit_breaker_state{account="test"} 0`,
+		`hh_resume_auto_boost_circuit_breaker_trips_total{account="test"} 2`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("missing metric: %v", line)
+		}
+	}
+}
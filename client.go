@@ -2,9 +2,6 @@ package main
 
 import (
 	"log/slog"
-	"math/rand/v2"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/imroc/req/v3"
@@ -12,36 +9,14 @@ import (
 	"golang.org/x/net/publicsuffix"
 )
 
-// generateGreasedChromeVersion builds a simulated GREASE string,
-// replicating the algorithm that Chromium uses.
-func generateGreasedChromeVersion(chromeVersion int) string {
-	greasedChars := []rune{' ', '(', ':', '-', '.', '/', ')', ';', '=', '?', '_'}
-	greasedVersions := []int{8, 99, 24}
-
-	v := strconv.Itoa(chromeVersion)
-
-	grease1 := string(greasedChars[rand.IntN(len(greasedChars))])             //nolint:gosec
-	grease2 := string(greasedChars[rand.IntN(len(greasedChars))])             //nolint:gosec
-	grease3 := strconv.Itoa(greasedVersions[rand.IntN(len(greasedVersions))]) //nolint:gosec
-
-	brands := []string{
-		`"Chromium";v="` + v + `"`,
-		`"Google Chrome";v="` + v + `"`,
-		`"Not` + grease1 + "A" + grease2 + `Brand";v="` + grease3 + `"`,
-	}
-
-	rand.Shuffle(len(brands), func(i, j int) {
-		brands[i], brands[j] = brands[j], brands[i]
-	})
-
-	return strings.Join(brands, ", ")
-}
-
-// createHTTPClient instantiates a req.Client that impersonates a generic, mainline Chrome browser.
+// createHTTPClient instantiates a req.Client that impersonates the browser of the configured fingerprint profile.
 func createHTTPClient(ctx *AppContext) *req.Client {
+	proxies := newProxyPool(&ctx.Cfg)
+
 	client := req.C()
 	if ctx.Cfg.HTTPDebug {
 		client.DevMode()
+		proxies.redactDebugOutput(client)
 	}
 
 	if ctx.Cfg.CookieJarFileName != "" {
@@ -61,26 +36,12 @@ func createHTTPClient(ctx *AppContext) *req.Client {
 	client.SetTLSHandshakeTimeout(25 * time.Second)
 	client.SetIdleConnTimeout(120 * time.Second)
 
-	userAgent := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/" + strconv.Itoa(ctx.Cfg.ChromeVersion) + ".0.0.0 Safari/537.36"
-
-	client.ImpersonateChrome()
-	client.SetCommonHeaders(map[string]string{
-		"User-Agent":         userAgent,
-		"Sec-Ch-Ua":          generateGreasedChromeVersion(ctx.Cfg.ChromeVersion),
-		"Sec-Ch-Ua-Platform": "\"Windows\"",
-		"Sec-Ch-Ua-Mobile":   "?0",
-
-		"Sec-Fetch-Site": "same-origin",
-		"Sec-Fetch-User": "?1",
-
-		"Accept-Language": "en,ru;q=0.9",
-		"Accept-Encoding": "gzip, deflate, br, zstd",
-	})
+	applyFingerprint(ctx, client)
+	newHARRecorder(ctx).instrument(client)
 
-	// These are set by ImpersonateChrome() and Chrome does not normally send them while interacting with HH,
-	// so we have to delete them
-	client.Headers.Del("Pragma")
-	client.Headers.Del("Cache-Control")
+	proxies.instrument(client)
+	ctx.Metrics.instrumentHTTPClient(ctx.Cfg.Name, client)
+	ctx.Breaker.instrument(client)
 
 	return client
 }
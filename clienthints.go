@@ -0,0 +1,284 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/imroc/req/v3"
+)
+
+// clientHintsSuffix is appended to the cookie jar file name to get the name of the client hints file.
+const clientHintsSuffix = "client-hints"
+
+// clientHintBrand is an entry of the Sec-Ch-Ua brand list.
+type clientHintBrand struct {
+	Brand       string `json:"brand"`
+	Version     string `json:"version"`
+	FullVersion string `json:"full_version"`
+}
+
+// clientHintsFile is the persistent state of the client hints.
+type clientHintsFile struct {
+	// ChromeVersion is the version that the brands have been generated for
+	ChromeVersion int `json:"chrome_version"`
+
+	// Brands are kept in the order in which they are sent
+	Brands []clientHintBrand `json:"brands"`
+
+	// AcceptCH maps the hosts to the lowercase names of the client hints that they have requested
+	AcceptCH map[string][]string `json:"accept_ch"`
+}
+
+// clientHints maintains the client hints of a Chromium-based browser.
+//
+// Chromium generates the GREASE brand and the brand order once per version,
+// so they are persisted alongside the cookie jar and reused until the Chrome version changes.
+// High-entropy hints are only sent to the hosts that have requested them with Accept-CH, like Chromium does.
+type clientHints struct {
+	fileName string
+	profile  *fingerprintProfile
+	log      *slog.Logger
+
+	// chromeVersion returns the Chrome version to impersonate, which may change at runtime
+	chromeVersion func() int
+
+	mu    sync.Mutex
+	state clientHintsFile
+}
+
+// newClientHints loads the client hints of the account, or generates them if they are missing or outdated.
+func newClientHints(ctx *AppContext, profile *fingerprintProfile) *clientHints {
+	ch := &clientHints{
+		profile: profile,
+		log:     ctx.Log,
+		chromeVersion: func() int {
+			return currentChromeVersion(ctx)
+		},
+	}
+
+	if ctx.Cfg.CookieJarFileName != "" {
+		ch.fileName = addFileNameSuffix(ctx.Cfg.CookieJarFileName, clientHintsSuffix)
+	}
+
+	err := ch.load()
+	if err != nil {
+		ch.log.Warn("failed to load client hints, generating new ones", "error", err)
+	}
+
+	ch.refresh()
+	return ch
+}
+
+// refresh regenerates the brands if the Chrome version has changed since they were generated.
+// The caller must hold mu, unless the hints are not in use yet.
+func (ch *clientHints) refresh() {
+	chromeVersion := ch.chromeVersion()
+	if ch.state.ChromeVersion == chromeVersion && ch.brand() != nil {
+		return
+	}
+
+	ch.state.ChromeVersion = chromeVersion
+	ch.state.Brands = generateClientHintBrands(ch.profile.brand, chromeVersion)
+
+	err := ch.save()
+	if err != nil {
+		ch.log.Warn("failed to save client hints", "error", err)
+	}
+}
+
+// generateClientHintBrands builds a simulated GREASE brand list for a Chromium-based browser,
+// replicating the algorithm that Chromium uses.
+func generateClientHintBrands(brand string, chromeVersion int) []clientHintBrand {
+	greasedChars := []rune{' ', '(', ':', '-', '.', '/', ')', ';', '=', '?', '_'}
+	greasedVersions := []int{8, 99, 24}
+
+	v := strconv.Itoa(chromeVersion)
+	fullVersion := v + ".0." + strconv.Itoa(chromeBuildNumber(chromeVersion)) + "." + strconv.Itoa(50+rand.IntN(150)) //nolint:gosec
+
+	grease1 := string(greasedChars[rand.IntN(len(greasedChars))])             //nolint:gosec
+	grease2 := string(greasedChars[rand.IntN(len(greasedChars))])             //nolint:gosec
+	grease3 := strconv.Itoa(greasedVersions[rand.IntN(len(greasedVersions))]) //nolint:gosec
+
+	brands := []clientHintBrand{
+		{Brand: "Chromium", Version: v, FullVersion: fullVersion},
+		{Brand: brand, Version: v, FullVersion: fullVersion},
+		{Brand: "Not" + grease1 + "A" + grease2 + "Brand", Version: grease3, FullVersion: grease3 + ".0.0.0"},
+	}
+
+	rand.Shuffle(len(brands), func(i, j int) {
+		brands[i], brands[j] = brands[j], brands[i]
+	})
+
+	return brands
+}
+
+// chromeBuildNumber approximates the build number of a Chrome version:
+// it grows by about 62 with every major version (e.g. 120.0.6099 and 140.0.7339).
+func chromeBuildNumber(chromeVersion int) int {
+	return 6099 + (chromeVersion-120)*62
+}
+
+// load reads the client hints file, if there is one.
+func (ch *clientHints) load() error {
+	if ch.fileName == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(ch.fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("reading client hints file: %w", err)
+	}
+
+	var state clientHintsFile
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return fmt.Errorf("decoding client hints file: %w", err)
+	}
+
+	ch.state = state
+	return nil
+}
+
+// save writes the client hints file. The caller must hold mu, unless the hints are not in use yet.
+func (ch *clientHints) save() error {
+	if ch.fileName == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(&ch.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding client hints: %w", err)
+	}
+
+	err = os.WriteFile(ch.fileName, data, 0o600)
+	if err != nil {
+		return fmt.Errorf("writing client hints file: %w", err)
+	}
+
+	return nil
+}
+
+// brand returns the brand list entry of the impersonated browser.
+func (ch *clientHints) brand() *clientHintBrand {
+	for i := range ch.state.Brands {
+		if ch.state.Brands[i].Brand == ch.profile.brand {
+			return &ch.state.Brands[i]
+		}
+	}
+
+	return nil
+}
+
+// brandList formats the brand list, either with the major or with the full versions.
+func (ch *clientHints) brandList(full bool) string {
+	brands := make([]string, 0, len(ch.state.Brands))
+	for _, b := range ch.state.Brands {
+		v := b.Version
+		if full {
+			v = b.FullVersion
+		}
+
+		brands = append(brands, strconv.Quote(b.Brand)+";v="+strconv.Quote(v))
+	}
+
+	return strings.Join(brands, ", ")
+}
+
+// highEntropyHint returns the value of a high-entropy client hint by its lowercase name,
+// or an empty string if the hint is not supported. The caller must hold mu.
+func (ch *clientHints) highEntropyHint(name string) string {
+	switch name {
+	case "sec-ch-ua-full-version-list":
+		return ch.brandList(true)
+	case "sec-ch-ua-full-version":
+		return strconv.Quote(ch.brand().FullVersion)
+	case "sec-ch-ua-arch":
+		return strconv.Quote(ch.profile.arch)
+	case "sec-ch-ua-bitness":
+		return `"64"`
+	case "sec-ch-ua-platform-version":
+		return strconv.Quote(ch.profile.platformVersion)
+	case "sec-ch-ua-model":
+		return `""`
+	case "sec-ch-ua-wow64":
+		return "?0"
+	case "sec-ch-ua-form-factors":
+		return `"Desktop"`
+	}
+
+	return ""
+}
+
+// instrument makes the client send the brand list with every request,
+// and the high-entropy hints with the requests to the hosts that have asked for them.
+func (ch *clientHints) instrument(cl *req.Client) {
+	cl.WrapRoundTripFunc(func(rt req.RoundTripper) req.RoundTripFunc {
+		return func(r *req.Request) (*req.Response, error) {
+			host := ""
+			if r.URL != nil {
+				host = r.URL.Host
+			}
+
+			ch.mu.Lock()
+			ch.refresh()
+			r.Headers.Set("Sec-Ch-Ua", ch.brandList(false))
+			for _, name := range ch.state.AcceptCH[host] {
+				if value := ch.highEntropyHint(name); value != "" {
+					r.Headers.Set(name, value)
+				}
+			}
+			ch.mu.Unlock()
+
+			resp, err := rt.RoundTrip(r)
+			if err == nil && resp.Response != nil {
+				if values := resp.Header.Values("Accept-Ch"); len(values) > 0 {
+					ch.accept(host, values)
+				}
+			}
+
+			return resp, err
+		}
+	})
+}
+
+// accept remembers the client hints that a host has requested with Accept-CH.
+// Every Accept-CH header replaces the hints that the host has requested before.
+func (ch *clientHints) accept(host string, values []string) {
+	// Resolving the hints reads the brands, which may be regenerated concurrently
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	var names []string
+	for _, value := range values {
+		for name := range strings.SplitSeq(value, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if ch.highEntropyHint(name) != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	if strings.Join(ch.state.AcceptCH[host], ",") == strings.Join(names, ",") {
+		return
+	}
+
+	if ch.state.AcceptCH == nil {
+		ch.state.AcceptCH = map[string][]string{}
+	}
+
+	ch.state.AcceptCH[host] = names
+
+	err := ch.save()
+	if err != nil {
+		ch.log.Warn("failed to save client hints", "error", err)
+	}
+}
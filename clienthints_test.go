@@ -0,0 +1,175 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/imroc/req/v3"
+)
+
+// newClientHintsServer starts a server that requests the client hints with Accept-CH
+// and records the headers of the last request.
+func newClientHintsServer(t *testing.T, acceptCH string) (*httptest.Server, func() http.Header) {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		headers http.Header
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = r.Header.Clone()
+		mu.Unlock()
+
+		if acceptCH != "" {
+			w.Header().Set("Accept-CH", acceptCH)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, func() http.Header {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return headers
+	}
+}
+
+// TestClientHintsPersistence checks that the GREASE brand list is kept across restarts
+// until the Chrome version changes.
+func TestClientHintsPersistence(t *testing.T) {
+	srv, lastHeaders := newClientHintsServer(t, "")
+	jarFileName := filepath.Join(t.TempDir(), "cookies.json")
+
+	secChUa := func(chromeVersion int) string {
+		t.Helper()
+
+		ctx := newTestAppContext(t, srv.URL)
+		ctx.Cfg.CookieJarFileName = jarFileName
+		ctx.Cfg.ChromeVersion = chromeVersion
+
+		if _, err := createHTTPClient(ctx).R().Get(srv.URL); err != nil {
+			t.Fatalf("sending request: %v", err)
+		}
+
+		return lastHeaders().Get("Sec-Ch-Ua")
+	}
+
+	first := secChUa(147)
+	for range 5 {
+		if v := secChUa(147); v != first {
+			t.Fatalf("brand list has changed: got %q, expected %q", v, first)
+		}
+	}
+
+	if v := secChUa(148); !strings.Contains(v, `"Google Chrome";v="148"`) {
+		t.Errorf("brand list has not been regenerated: %q", v)
+	}
+}
+
+// TestClientHintsAcceptCH checks that the high-entropy hints are only sent after HH requests them,
+// and that they are consistent with the brand list.
+func TestClientHintsAcceptCH(t *testing.T) {
+	srv, lastHeaders := newClientHintsServer(t, "Sec-CH-UA-Full-Version-List, Sec-CH-UA-Arch, Sec-CH-UA-Platform-Version, Sec-CH-UA-Unknown")
+	jarFileName := filepath.Join(t.TempDir(), "cookies.json")
+
+	newClient := func() *AppContext {
+		ctx := newTestAppContext(t, srv.URL)
+		ctx.Cfg.CookieJarFileName = jarFileName
+		ctx.Cfg.ChromeVersion = 147
+		ctx.Cfg.Fingerprint.Profile = "chrome-macos"
+
+		return ctx
+	}
+
+	cl := createHTTPClient(newClient())
+	if _, err := cl.R().Get(srv.URL); err != nil {
+		t.Fatalf("sending request: %v", err)
+	}
+
+	if v := lastHeaders().Get("Sec-Ch-Ua-Full-Version-List"); v != "" {
+		t.Fatalf("high-entropy hint has been sent before it was requested: %q", v)
+	}
+
+	// The requested hints are remembered across restarts
+	for _, cl := range []*req.Client{cl, createHTTPClient(newClient())} {
+		if _, err := cl.R().Get(srv.URL); err != nil {
+			t.Fatalf("sending request: %v", err)
+		}
+
+		headers := lastHeaders()
+
+		fullVersionList := headers.Get("Sec-Ch-Ua-Full-Version-List")
+		if !strings.Contains(fullVersionList, `"Google Chrome";v="147.0.`+strconv.Itoa(chromeBuildNumber(147))+".") {
+			t.Errorf("invalid full version list: %q", fullVersionList)
+		}
+
+		// The brands have to be listed in the same order
+		brands := strings.Split(headers.Get("Sec-Ch-Ua"), ", ")
+		fullBrands := strings.Split(fullVersionList, ", ")
+		for i := range brands {
+			brand, _, _ := strings.Cut(brands[i], ";")
+			if i >= len(fullBrands) || !strings.HasPrefix(fullBrands[i], brand+";") {
+				t.Errorf("brand lists do not match: %q and %q", brands, fullBrands)
+				break
+			}
+		}
+
+		if v := headers.Get("Sec-Ch-Ua-Arch"); v != `"arm"` {
+			t.Errorf("invalid architecture: got %q, expected %q", v, `"arm"`)
+		}
+
+		if v := headers.Get("Sec-Ch-Ua-Platform-Version"); v != `"15.5.0"` {
+			t.Errorf("invalid platform version: got %q, expected %q", v, `"15.5.0"`)
+		}
+
+		if v := headers.Get("Sec-Ch-Ua-Bitness"); v != "" {
+			t.Errorf("unrequested hint has been sent: %q", v)
+		}
+	}
+}
+
+// TestClientHintsConcurrentAccept checks that Accept-CH can be handled while the brands are regenerated
+// for a new Chrome version; it is only meaningful with the race detector.
+func TestClientHintsConcurrentAccept(t *testing.T) {
+	version := &atomic.Int32{}
+	version.Store(147)
+
+	ch := &clientHints{
+		profile: fingerprintProfiles["chrome-windows"],
+		log:     slog.Default(),
+		chromeVersion: func() int {
+			return int(version.Load())
+		},
+	}
+	ch.refresh()
+
+	wg := sync.WaitGroup{}
+	wg.Go(func() {
+		for range 100 {
+			ch.accept("hh.ru", []string{"Sec-CH-UA-Full-Version-List, Sec-CH-UA-Full-Version"})
+		}
+	})
+	wg.Go(func() {
+		for range 100 {
+			version.Add(1)
+
+			ch.mu.Lock()
+			ch.refresh()
+			ch.mu.Unlock()
+		}
+	})
+	wg.Wait()
+
+	if names := ch.state.AcceptCH["hh.ru"]; len(names) != 2 {
+		t.Errorf("invalid accepted hints: %v", names)
+	}
+}
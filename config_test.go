@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -137,6 +139,168 @@ func TestValidate(t *testing.T) {
 			name:   "discover backoff is too low",
 			mutate: func(c *Config) { c.DiscoverBackoffDelay = time.Second },
 		},
+		{
+			name:   "invalid otp source",
+			mutate: func(c *Config) { c.OTP.Source = "carrier pigeon" },
+		},
+		{
+			name:   "http otp source without admin api",
+			mutate: func(c *Config) { c.OTP.Source = otpSourceHTTP },
+		},
+		{
+			name:   "invalid captcha mode",
+			mutate: func(c *Config) { c.Captcha.Mode = "telepathy" },
+		},
+		{
+			name:   "web captcha mode without admin api",
+			mutate: func(c *Config) { c.Captcha.Mode = captchaModeWeb },
+		},
+		{
+			name:   "telegram without chat id",
+			mutate: func(c *Config) { c.Notifications.Telegram.BotToken = "123456:token" },
+		},
+		{
+			name:   "boost failure threshold is too low",
+			mutate: func(c *Config) { c.Notifications.BoostFailureThreshold = 0 },
+		},
+		{
+			name: "invalid webhook template",
+			mutate: func(c *Config) {
+				c.Notifications.Webhook.URL = "https://example.com/hook"
+				c.Notifications.Webhook.Template = "{{ .Text"
+			},
+		},
+		{
+			name: "unknown webhook event kind",
+			mutate: func(c *Config) {
+				c.Notifications.Webhook.URL = "https://example.com/hook"
+				c.Notifications.Webhook.Events = []string{"resume_exploded"}
+			},
+		},
+		{
+			name: "invalid smtp security mode",
+			mutate: func(c *Config) {
+				c.Notifications.Email.Host = "smtp.example.com"
+				c.Notifications.Email.Security = "ssl"
+				c.Notifications.Email.From = "boost@example.com"
+				c.Notifications.Email.To = []string{"me@example.com"}
+			},
+		},
+		{
+			name: "email digest without recipients",
+			mutate: func(c *Config) {
+				c.Notifications.Email.Host = "smtp.example.com"
+				c.Notifications.Email.From = "boost@example.com"
+			},
+		},
+		{
+			name: "invalid holiday date",
+			mutate: func(c *Config) {
+				c.Holidays.Dates = []string{"01.01.2026"}
+			},
+		},
+		{
+			name: "holiday boost interval is lower than the regular one",
+			mutate: func(c *Config) {
+				c.Holidays.BoostInterval = time.Hour
+			},
+		},
+		{
+			name: "invalid jitter distribution",
+			mutate: func(c *Config) {
+				c.Jitter.Distribution = "poisson"
+			},
+		},
+		{
+			name: "negative jitter",
+			mutate: func(c *Config) {
+				c.Jitter.Distribution = jitterUniform
+				c.Jitter.Min = -time.Minute
+			},
+		},
+		{
+			name: "resume override without matchers",
+			mutate: func(c *Config) {
+				c.ResumeOverrides = []ResumeOverride{{Priority: 1}}
+			},
+		},
+		{
+			name: "resume override with invalid windows",
+			mutate: func(c *Config) {
+				c.ResumeOverrides = []ResumeOverride{{IDs: []string{"abc"}, Windows: []BoostWindow{{Start: "10:00", End: "09:00"}}}}
+			},
+		},
+		{
+			name: "minimum gap between boosts exceeds the boost interval",
+			mutate: func(c *Config) {
+				c.Stagger.MinGap = 5 * time.Hour
+			},
+		},
+		{
+			name: "boost retry initial delay is too low",
+			mutate: func(c *Config) {
+				c.BoostRetry.ServerError.InitialDelay = time.Second
+			},
+		},
+		{
+			name: "boost retry maximum delay is lower than the initial one",
+			mutate: func(c *Config) {
+				c.BoostRetry.RateLimited.MaxDelay = time.Minute
+			},
+		},
+		{
+			name: "negative boost retry attempts",
+			mutate: func(c *Config) {
+				c.BoostRetry.NotFound.MaxAttempts = -1
+			},
+		},
+		{
+			name: "negative circuit breaker threshold",
+			mutate: func(c *Config) {
+				c.CircuitBreaker.Threshold = -1
+			},
+		},
+		{
+			name: "circuit breaker cooldown is too low",
+			mutate: func(c *Config) {
+				c.CircuitBreaker.Cooldown = time.Second
+			},
+		},
+		{
+			name: "unsupported proxy scheme",
+			mutate: func(c *Config) {
+				c.Proxy.URL = "ftp://127.0.0.1:21"
+			},
+		},
+		{
+			name: "invalid proxy mode",
+			mutate: func(c *Config) {
+				c.Proxy.URL = "socks5://127.0.0.1:1080"
+				c.Proxy.Mode = "random"
+			},
+		},
+		{
+			name:   "unknown fingerprint profile",
+			mutate: func(c *Config) { c.Fingerprint.Profile = "opera-windows" },
+		},
+		{
+			name: "invalid HAR file size limit",
+			mutate: func(c *Config) {
+				c.HAR.FileName = "traffic.har"
+				c.HAR.MaxSizeMB = 0
+			},
+		},
+		{
+			name:   "invalid number of HAR file backups",
+			mutate: func(c *Config) { c.HAR.MaxBackups = -1 },
+		},
+		{
+			name: "otp timeout is too low",
+			mutate: func(c *Config) {
+				c.OTP.Source = otpSourceStdin
+				c.OTP.Timeout = time.Second
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -240,6 +404,91 @@ func TestLoadFromJSON(t *testing.T) {
 			t.Errorf("allowed_resumes substrings are not lowercased: %v", cfg.AllowedResumes.Substrings)
 		}
 	})
+
+	t.Run("duration strings", func(t *testing.T) {
+		path := writeTempFile(`{
+			"boost_interval": "4h2m",
+			"discover_interval": 60000000000,
+			"jitter": {"min": "30s", "max": "10m"},
+			"resume_overrides": [{"ids": ["abc"], "boost_interval": "8h"}],
+			"boost_retry": {"server_error": {"initial_delay": "5m", "max_delay": "2h"}}
+		}`)
+
+		cfg := Config{}
+		cfg.Instantiate()
+		if err := cfg.LoadFromJSON(path); err != nil {
+			t.Fatalf("loading config: %v", err)
+		}
+
+		if cfg.BoostInterval != 4*time.Hour+2*time.Minute {
+			t.Errorf("invalid boost interval: got %v, expected %v", cfg.BoostInterval, 4*time.Hour+2*time.Minute)
+		}
+
+		if cfg.DiscoverInterval != time.Minute {
+			t.Errorf("invalid discover interval: got %v, expected %v", cfg.DiscoverInterval, time.Minute)
+		}
+
+		if cfg.Jitter.Min != 30*time.Second || cfg.Jitter.Max != 10*time.Minute {
+			t.Errorf("invalid jitter: got %v-%v, expected %v-%v", cfg.Jitter.Min, cfg.Jitter.Max, 30*time.Second, 10*time.Minute)
+		}
+
+		if len(cfg.ResumeOverrides) != 1 || cfg.ResumeOverrides[0].BoostInterval != 8*time.Hour {
+			t.Errorf("invalid resume overrides: %+v", cfg.ResumeOverrides)
+		}
+
+		policy := cfg.boostRetryPolicy(boostErrorServerError)
+		if policy.InitialDelay != 5*time.Minute || policy.MaxDelay != 2*time.Hour {
+			t.Errorf("invalid retry policy: got %+v", policy)
+		}
+	})
+
+	t.Run("invalid duration", func(t *testing.T) {
+		path := writeTempFile(`{"boost_interval": "4 hours"}`)
+
+		cfg := Config{}
+		if err := cfg.LoadFromJSON(path); err == nil {
+			t.Fatal("expected error for invalid duration")
+		}
+	})
+}
+
+// TestREADMEExamples checks that every config example in the README can be loaded.
+func TestREADMEExamples(t *testing.T) {
+	readme, err := os.ReadFile("README.md")
+	if err != nil {
+		t.Fatalf("reading README: %v", err)
+	}
+
+	var examples []string
+	for _, block := range strings.Split(string(readme), "```json\n")[1:] {
+		example, _, ok := strings.Cut(block, "```")
+		if ok && !strings.Contains(example, `"kind"`) { // Event payloads are not configs
+			examples = append(examples, example)
+		}
+	}
+
+	if len(examples) == 0 {
+		t.Fatal("no config examples found in README")
+	}
+
+	for i, example := range examples {
+		t.Run(strconv.Itoa(i+1), func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(example), 0o600); err != nil {
+				t.Fatalf("writing temp file: %v", err)
+			}
+
+			cfg := Config{}
+			cfg.Instantiate()
+			if err := cfg.LoadFromJSON(path); err != nil {
+				t.Fatalf("loading example:\n%v\nerror: %v", example, err)
+			}
+
+			if _, err := cfg.AccountConfigs(); err != nil {
+				t.Fatalf("resolving accounts of example:\n%v\nerror: %v", example, err)
+			}
+		})
+	}
 }
 
 // TestLoad checks various scenarios when loading the config from environment variables.
@@ -305,3 +554,171 @@ func TestLoadFromEnv(t *testing.T) {
 		}
 	})
 }
+
+// TestAccountConfigs checks that account configs inherit the top-level values.
+func TestAccountConfigs(t *testing.T) {
+	loadConfig := func(t *testing.T, content string) *Config {
+		t.Helper()
+
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("writing temp file: %v", err)
+		}
+
+		cfg := &Config{}
+		cfg.Instantiate()
+		if err := cfg.LoadFromJSON(path); err != nil {
+			t.Fatalf("loading config: %v", err)
+		}
+
+		return cfg
+	}
+
+	t.Run("single account", func(t *testing.T) {
+		cfg := loadConfig(t, `{"login": "+78005553535", "password": "Bash1234"}`)
+
+		accounts, err := cfg.AccountConfigs()
+		if err != nil {
+			t.Fatalf("resolving accounts: %v", err)
+		}
+
+		if len(accounts) != 1 || accounts[0].Name != "+78005553535" {
+			t.Fatalf("invalid accounts: %+v", accounts)
+		}
+
+		if accounts[0].CookieJarFileName != "cookies.json" {
+			t.Errorf("invalid cookie jar: got %q, expected %q", accounts[0].CookieJarFileName, "cookies.json")
+		}
+	})
+
+	t.Run("multiple accounts", func(t *testing.T) {
+		cfg := loadConfig(t, `{
+			"password": "Shared1234",
+			"boost_interval": 18000000000000,
+			"ignored_resumes": {"substrings": ["Draft"]},
+			"accounts": [
+				{"name": "alice", "login": "alice@example.com"},
+				{
+					"name": "bob/2",
+					"login": "bob@example.com",
+					"password": "Bob1234",
+					"cookie_jar_file_name": "bob.json",
+					"ignored_resumes": {"ids": ["ABC"]}
+				}
+			]
+		}`)
+
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("validating config: %v", err)
+		}
+
+		accounts, err := cfg.AccountConfigs()
+		if err != nil {
+			t.Fatalf("resolving accounts: %v", err)
+		}
+
+		if len(accounts) != 2 {
+			t.Fatalf("invalid number of accounts: got %v, expected 2", len(accounts))
+		}
+
+		alice, bob := accounts[0], accounts[1]
+
+		if alice.Password != "Shared1234" || bob.Password != "Bob1234" {
+			t.Errorf("invalid passwords: %q, %q", alice.Password, bob.Password)
+		}
+
+		if alice.BoostInterval != 5*time.Hour || bob.BoostInterval != 5*time.Hour {
+			t.Errorf("boost interval should have been inherited: %v, %v", alice.BoostInterval, bob.BoostInterval)
+		}
+
+		if alice.CookieJarFileName != "cookies.alice.json" {
+			t.Errorf("invalid cookie jar: got %q, expected %q", alice.CookieJarFileName, "cookies.alice.json")
+		}
+
+		if bob.CookieJarFileName != "bob.json" {
+			t.Errorf("invalid cookie jar: got %q, expected %q", bob.CookieJarFileName, "bob.json")
+		}
+
+		if bob.OTP.FileName != "otp.bob_2.txt" {
+			t.Errorf("invalid otp file: got %q, expected %q", bob.OTP.FileName, "otp.bob_2.txt")
+		}
+
+		if !slices.Equal(bob.IgnoredResumes.IDs, []string{"abc"}) || !slices.Equal(bob.IgnoredResumes.Substrings, []string{"draft"}) {
+			t.Errorf("invalid ignore list: %+v", bob.IgnoredResumes)
+		}
+
+		if len(alice.IgnoredResumes.IDs) != 0 {
+			t.Errorf("ignore list should not leak between accounts: %v", alice.IgnoredResumes.IDs)
+		}
+	})
+
+	t.Run("duplicate names", func(t *testing.T) {
+		cfg := loadConfig(t, `{
+			"password": "Shared1234",
+			"accounts": [{"name": "a", "login": "1"}, {"name": "a", "login": "2"}]
+		}`)
+
+		if err := cfg.Validate(); err == nil {
+			t.Fatal("expected error for duplicate account names")
+		}
+	})
+
+	t.Run("shared files", func(t *testing.T) {
+		cfg := loadConfig(t, `{
+			"password": "Shared1234",
+			"accounts": [{"login": "1", "cookie_jar_file_name": "c.json"}, {"login": "2", "cookie_jar_file_name": "c.json"}]
+		}`)
+
+		if err := cfg.Validate(); err == nil {
+			t.Fatal("expected error for a shared cookie jar")
+		}
+	})
+
+	t.Run("missing login", func(t *testing.T) {
+		cfg := loadConfig(t, `{"password": "Shared1234", "accounts": [{"name": "a"}]}`)
+
+		if err := cfg.Validate(); err == nil {
+			t.Fatal("expected error for a missing login")
+		}
+	})
+
+	t.Run("nested accounts", func(t *testing.T) {
+		cfg := loadConfig(t, `{"accounts": [{"login": "1", "accounts": [{"login": "2"}]}]}`)
+
+		if _, err := cfg.AccountConfigs(); err == nil {
+			t.Fatal("expected error for nested accounts")
+		}
+	})
+
+	t.Run("duration strings", func(t *testing.T) {
+		cfg := loadConfig(t, `{"boost_interval": "5h", "accounts": [{"login": "1"}, {"login": "2", "boost_interval": "6h"}]}`)
+
+		accounts, err := cfg.AccountConfigs()
+		if err != nil {
+			t.Fatalf("resolving accounts: %v", err)
+		}
+
+		if accounts[0].BoostInterval != 5*time.Hour || accounts[1].BoostInterval != 6*time.Hour {
+			t.Errorf("invalid boost intervals: got %v, %v, expected %v, %v",
+				accounts[0].BoostInterval, accounts[1].BoostInterval, 5*time.Hour, 6*time.Hour)
+		}
+	})
+
+	t.Run("accounts from env", func(t *testing.T) {
+		cfg := Config{}
+		t.Setenv("ACCOUNTS", `[{"login": "1"}, {"login": "2"}]`)
+
+		if err := cfg.LoadFromEnv(); err != nil {
+			t.Fatalf("loading config from env: %v", err)
+		}
+
+		accounts, err := cfg.AccountConfigs()
+		if err != nil {
+			t.Fatalf("resolving accounts: %v", err)
+		}
+
+		if len(accounts) != 2 || accounts[1].Login != "2" {
+			t.Errorf("invalid accounts: %+v", accounts)
+		}
+	})
+}
@@ -1,10 +1,34 @@
 package main
 
-import "context"
+import (
+	"context"
+	"log/slog"
+)
 
 // AppContext is an enriched implementation of context.Context.
 type AppContext struct {
 	context.Context //nolint:containedctx
 
 	Cfg Config
+
+	// Log is the logger of the account that the context belongs to
+	Log *slog.Logger
+
+	// Metrics is shared between all accounts
+	Metrics *appMetrics
+
+	// Health tracks the health of the account that the context belongs to
+	Health *accountHealth
+
+	// Notifier delivers the notifications of the account
+	Notifier *notificationHub
+
+	// Jitter randomizes the timers of the account; nil if jitter is disabled
+	Jitter *jitter
+
+	// Breaker pauses the requests of the account while HH is down; nil if it is disabled
+	Breaker *circuitBreaker
+
+	// Chrome follows the Chrome version file; nil if the Chrome version does not change at runtime
+	Chrome *chromeVersionSource
 }
@@ -1,25 +1,85 @@
 package main
 
 import (
+	"errors"
 	"iter"
-	"log/slog"
+	"slices"
+	"sync/atomic"
 	"time"
-
-	"github.com/imroc/req/v3"
 )
 
-// discoverResumes keeps repeatedly yielding resume instances according to the DiscoverInterval.
+var errDiscoveryNotRunning = errors.New("resume discovery is not running")
+
+// discoveryTrigger allows forcing an immediate discovery pass
+// instead of waiting for the next one.
+type discoveryTrigger struct {
+	ch      chan struct{}
+	running atomic.Bool
+}
+
+func newDiscoveryTrigger() *discoveryTrigger {
+	return &discoveryTrigger{
+		ch: make(chan struct{}, 1),
+	}
+}
+
+// trigger requests an immediate discovery pass.
+func (dt *discoveryTrigger) trigger() error {
+	if !dt.running.Load() {
+		return errDiscoveryNotRunning
+	}
+
+	// Non-blocking send: a pending request is already enough
+	select {
+	case dt.ch <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// discoverResumes keeps repeatedly yielding the list of eligible resumes according to the DiscoverInterval.
+// Each yielded slice is the complete result of a single discovery pass.
 // If DiscoverInterval is zero, it performs the discovery only once and exits.
-func discoverResumes(ctx *AppContext, cl *req.Client) iter.Seq[*hhResume] {
-	return func(yield func(*hhResume) bool) {
+// While waiting for the next pass, the discovery may be forced with the trigger.
+func discoverResumes(ctx *AppContext, sess *hhSession, trigger *discoveryTrigger) iter.Seq[[]*hhResume] {
+	return func(yield func([]*hhResume) bool) {
+		trigger.running.Store(true)
+		defer trigger.running.Store(false)
+
 		consecutiveFailures := 0
 
+		// known holds the resumes from the last successful pass,
+		// so that the user can be notified about the resumes that come and go
+		known := map[string]*hhResume{}
+
 		for {
-			slog.Debug("discovering resumes")
+			// Discovery is not attempted while HH is down
+			select {
+			case <-ctx.Breaker.ready():
+			default:
+				ctx.Log.Info("waiting for HH to come back before discovering resumes")
+
+				select {
+				case <-ctx.Breaker.ready():
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			ctx.Log.Debug("discovering resumes")
+			ctx.Metrics.discoveryRuns.WithLabelValues(ctx.Cfg.Name).Inc()
+
+			resumes, err := hhGetResumes(ctx, sess)
+			if errors.Is(err, errCircuitOpen) {
+				continue
+			}
+
+			ctx.Health.recordDiscovery(err)
 
-			resumes, err := hhGetResumes(ctx, cl, false)
 			if err != nil {
-				slog.Error("failed to get resume list", "error", err)
+				ctx.Log.Error("failed to get resume list", "error", err)
+				ctx.Metrics.discoveryFailures.WithLabelValues(ctx.Cfg.Name).Inc()
 
 				// If we are not set up for rediscovery, return instantly
 				if ctx.Cfg.DiscoverInterval == 0 {
@@ -28,16 +88,27 @@ func discoverResumes(ctx *AppContext, cl *req.Client) iter.Seq[*hhResume] {
 
 				consecutiveFailures++
 				if consecutiveFailures >= 3 {
-					slog.Error("too many consecutive resume discovery failures, stopping discovery")
+					ctx.Log.Error("too many consecutive resume discovery failures, stopping discovery")
+					ctx.Health.stopDiscovery()
+					ctx.Notifier.publish(ctx, event{
+						Kind:     eventDiscoveryStopped,
+						Failures: consecutiveFailures,
+						Error:    err.Error(),
+					})
 					return
 				}
 
 				// wait a bit and retry
-				slog.Info("scheduled next discovery retry", "wait_for", ctx.Cfg.DiscoverBackoffDelay)
-				timer := time.NewTimer(ctx.Cfg.DiscoverBackoffDelay)
+				backoffDelay := ctx.Jitter.apply(ctx.Cfg.DiscoverBackoffDelay)
+				ctx.Log.Info("scheduled next discovery retry", "wait_for", backoffDelay)
+				timer := time.NewTimer(backoffDelay)
 				select {
 				case <-timer.C:
 					continue
+				case <-trigger.ch:
+					timer.Stop()
+					ctx.Log.Info("resume discovery has been forced")
+					continue
 				case <-ctx.Done():
 					return
 				}
@@ -45,25 +116,52 @@ func discoverResumes(ctx *AppContext, cl *req.Client) iter.Seq[*hhResume] {
 
 			consecutiveFailures = 0
 
-			for resume := range resumes {
-				if !yield(resume) {
-					// Intentionally return here: we will have to tear down the discovery process anyway
-					// if our consumer has stopped
-					return
-				}
+			discovered := slices.Collect(resumes)
+			known = notifyResumeChanges(ctx, known, discovered)
+
+			if !yield(discovered) {
+				// Intentionally return here: we will have to tear down the discovery process anyway
+				// if our consumer has stopped
+				return
 			}
 
 			if ctx.Cfg.DiscoverInterval == 0 {
 				return
 			}
 
-			slog.Info("scheduled next discovery", "wait_for", ctx.Cfg.DiscoverInterval)
-			timer := time.NewTimer(ctx.Cfg.DiscoverInterval)
+			interval := ctx.Jitter.apply(ctx.Cfg.DiscoverInterval)
+			ctx.Log.Info("scheduled next discovery", "wait_for", interval)
+			timer := time.NewTimer(interval)
 			select {
 			case <-timer.C:
+			case <-trigger.ch:
+				timer.Stop()
+				ctx.Log.Info("resume discovery has been forced")
 			case <-ctx.Done():
 				return
 			}
 		}
 	}
 }
+
+// notifyResumeChanges publishes the events for the resumes that have appeared or disappeared
+// since the previous discovery pass, and returns the new set of known resumes.
+func notifyResumeChanges(ctx *AppContext, known map[string]*hhResume, discovered []*hhResume) map[string]*hhResume {
+	current := make(map[string]*hhResume, len(discovered))
+
+	for _, resume := range discovered {
+		current[resume.id] = resume
+
+		if _, ok := known[resume.id]; !ok {
+			ctx.Notifier.publish(ctx, event{Kind: eventResumeDiscovered, ResumeID: resume.id, ResumeTitle: resume.title})
+		}
+	}
+
+	for id, resume := range known {
+		if _, ok := current[id]; !ok {
+			ctx.Notifier.publish(ctx, event{Kind: eventResumeEvicted, ResumeID: id, ResumeTitle: resume.title})
+		}
+	}
+
+	return current
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"net"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	emailSecurityStartTLS = "starttls"
+	emailSecurityTLS      = "tls"
+	emailSecurityNone     = "none"
+)
+
+// emailTimeout limits the duration of a single SMTP session.
+const emailTimeout = time.Minute
+
+// maxDigestFailures limits the number of failure reasons that are listed per resume.
+const maxDigestFailures = 10
+
+// digestResume is the boost activity of a single resume since the last digest.
+type digestResume struct {
+	title     string
+	succeeded int
+	failed    int
+	failures  []string
+	nextBoost time.Time
+	evicted   bool
+}
+
+// emailDigest accumulates the events and periodically sends a summary of them via SMTP.
+type emailDigest struct {
+	host     string
+	port     int
+	security string
+	username string
+	password string
+	from     string
+	to       []string
+	interval time.Duration
+
+	// mu guards the fields below
+	mu      sync.Mutex
+	since   time.Time
+	resumes map[string]*digestResume
+	other   []string
+}
+
+func newEmailDigest(ctx *AppContext) *emailDigest {
+	cfg := &ctx.Cfg.Notifications.Email
+
+	return &emailDigest{
+		host:     cfg.Host,
+		port:     cfg.Port,
+		security: cfg.Security,
+		username: cfg.Username,
+		password: cfg.Password,
+		from:     cfg.From,
+		to:       cfg.To,
+		interval: cfg.Interval,
+
+		since:   time.Now(),
+		resumes: map[string]*digestResume{},
+	}
+}
+
+func (d *emailDigest) name() string {
+	return "email"
+}
+
+// notify records the event for the next digest.
+func (d *emailDigest) notify(_ context.Context, ev *event) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	resume := func() *digestResume {
+		dr, ok := d.resumes[ev.ResumeID]
+		if !ok {
+			dr = &digestResume{}
+			d.resumes[ev.ResumeID] = dr
+		}
+
+		dr.title = ev.ResumeTitle
+		return dr
+	}
+
+	switch ev.Kind {
+	case eventBoostScheduled:
+		dr := resume()
+		dr.nextBoost = ev.NextBoost
+		dr.evicted = false
+	case eventBoostSucceeded:
+		resume().succeeded++
+	case eventBoostFailed:
+		dr := resume()
+		dr.failed++
+		if len(dr.failures) < maxDigestFailures {
+			dr.failures = append(dr.failures, ev.Time.Format(time.DateTime)+": "+ev.Error)
+		}
+	case eventResumeEvicted:
+		resume().evicted = true
+	case eventBoostSuspended, eventAuthFailed, eventCaptchaRequired, eventDiscoveryStopped:
+		d.other = append(d.other, ev.Time.Format(time.DateTime)+": "+ev.Text)
+	}
+
+	return nil
+}
+
+// run sends the digest every interval until the context is cancelled.
+func (d *emailDigest) run(ctx *AppContext) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := d.flush(ctx)
+			if err != nil {
+				ctx.Log.Error("failed to send email digest", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// flush sends the digest and resets the accumulated activity.
+// The activity is taken out under mu and sent without holding it, so that the events
+// keep being recorded during a slow SMTP session; if sending fails, the activity is put back
+// to be sent with the next digest.
+func (d *emailDigest) flush(ctx *AppContext) error {
+	d.mu.Lock()
+	now := time.Now()
+	body := d.render(ctx.Cfg.Name, now)
+	since, resumes, other := d.since, d.resumes, d.other
+	d.reset(now)
+	d.mu.Unlock()
+
+	err := d.send(ctx, "Boost digest for "+ctx.Cfg.Name, body)
+	if err != nil {
+		d.mu.Lock()
+		d.restore(since, resumes, other)
+		d.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// reset starts a new digest, keeping the schedule of the resumes that are still available.
+// The caller must hold mu.
+func (d *emailDigest) reset(now time.Time) {
+	resumes := make(map[string]*digestResume, len(d.resumes))
+	for id, dr := range d.resumes {
+		if !dr.evicted {
+			resumes[id] = &digestResume{title: dr.title, nextBoost: dr.nextBoost}
+		}
+	}
+
+	d.since = now
+	d.resumes = resumes
+	d.other = nil
+}
+
+// restore merges the activity of a digest that could not be sent into the current one.
+// The caller must hold mu.
+func (d *emailDigest) restore(since time.Time, resumes map[string]*digestResume, other []string) {
+	d.since = since
+	d.other = append(other, d.other...)
+
+	for id, prev := range resumes {
+		dr, ok := d.resumes[id]
+		if !ok {
+			d.resumes[id] = prev
+			continue
+		}
+
+		// The current entry already has the latest title, schedule and eviction state
+		dr.succeeded += prev.succeeded
+		dr.failed += prev.failed
+		dr.failures = append(prev.failures, dr.failures...)
+		dr.failures = dr.failures[:min(len(dr.failures), maxDigestFailures)]
+	}
+}
+
+// render formats the digest as plain text.
+// The caller must hold mu.
+func (d *emailDigest) render(account string, now time.Time) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "Boost activity of %v from %v to %v.\n", account, d.since.Format(time.DateTime), now.Format(time.DateTime))
+
+	if len(d.resumes) == 0 {
+		sb.WriteString("\nNo resumes have been scheduled.\n")
+	}
+
+	ids := slices.SortedFunc(maps.Keys(d.resumes), func(a, b string) int {
+		return strings.Compare(d.resumes[a].title, d.resumes[b].title)
+	})
+
+	for _, id := range ids {
+		dr := d.resumes[id]
+
+		fmt.Fprintf(sb, "\n%v (%v)\n", dr.title, id)
+		fmt.Fprintf(sb, "  Boosts: %v succeeded, %v failed\n", dr.succeeded, dr.failed)
+
+		for _, failure := range dr.failures {
+			fmt.Fprintf(sb, "  - %v\n", failure)
+		}
+
+		switch {
+		case dr.evicted:
+			sb.WriteString("  No longer available or eligible\n")
+		case !dr.nextBoost.IsZero():
+			fmt.Fprintf(sb, "  Next boost: %v\n", dr.nextBoost.Format(time.DateTime))
+		}
+	}
+
+	if len(d.other) > 0 {
+		sb.WriteString("\nOther events:\n")
+
+		for _, line := range d.other {
+			fmt.Fprintf(sb, "  - %v\n", line)
+		}
+	}
+
+	return sb.String()
+}
+
+// send delivers a plain text email via SMTP.
+func (d *emailDigest) send(ctx context.Context, subject, body string) error {
+	ctx, cancel := context.WithTimeout(ctx, emailTimeout)
+	defer cancel()
+
+	address := net.JoinHostPort(d.host, strconv.Itoa(d.port))
+	tlsConfig := &tls.Config{ServerName: d.host, MinVersion: tls.VersionTLS12}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if d.security == emailSecurityTLS {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		dialer := &net.Dialer{}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+
+	if err != nil {
+		return fmt.Errorf("connecting to SMTP server: %w", err)
+	}
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("setting SMTP connection deadline: %w", err)
+	}
+
+	c, err := smtp.NewClient(conn, d.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("starting SMTP session: %w", err)
+	}
+
+	defer func() {
+		_ = c.Close()
+	}()
+
+	if d.security == emailSecurityStartTLS {
+		err = c.StartTLS(tlsConfig)
+		if err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+
+	if d.username != "" {
+		err = c.Auth(smtp.PlainAuth("", d.username, d.password, d.host))
+		if err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	err = c.Mail(d.from)
+	if err != nil {
+		return fmt.Errorf("setting sender: %w", err)
+	}
+
+	for _, to := range d.to {
+		err = c.Rcpt(to)
+		if err != nil {
+			return fmt.Errorf("setting recipient %q: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("starting message: %w", err)
+	}
+
+	_, err = w.Write(buildEmailMessage(d.from, d.to, subject, body))
+	if err != nil {
+		_ = w.Close()
+		return fmt.Errorf("writing message: %w", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return fmt.Errorf("finishing message: %w", err)
+	}
+
+	err = c.Quit()
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("closing SMTP session: %w", err)
+	}
+
+	return nil
+}
+
+// buildEmailMessage builds an RFC 5322 message with a plain text body.
+func buildEmailMessage(from string, to []string, subject, body string) []byte {
+	headers := []string{
+		"From: " + from,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+
+	body = strings.ReplaceAll(body, "\n", "\r\n")
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + body)
+}
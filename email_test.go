@@ -0,0 +1,260 @@
+package main
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpMessage is an email received by the fake SMTP server.
+type smtpMessage struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// newFakeSMTPServer starts a minimal plaintext SMTP sink.
+// Every received message is sent to the returned channel.
+func newFakeSMTPServer(t *testing.T) (string, int, <-chan smtpMessage) {
+	t.Helper()
+
+	l, err := (&net.ListenConfig{}).Listen(t.Context(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	messages := make(chan smtpMessage, 4)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go serveFakeSMTP(conn, messages)
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, messages
+}
+
+func serveFakeSMTP(conn net.Conn, messages chan<- smtpMessage) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	tp := textproto.NewConn(conn)
+	msg := smtpMessage{}
+
+	reply := func(line string) bool {
+		return tp.PrintfLine("%s", line) == nil
+	}
+
+	if !reply("220 localhost ESMTP") {
+		return
+	}
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250-localhost\r\n250 AUTH PLAIN")
+		case "AUTH":
+			msg.auth = arg
+			reply("235 Authentication successful")
+		case "MAIL":
+			msg.from = arg
+			reply("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, arg)
+			reply("250 OK")
+		case "DATA":
+			reply("354 Go ahead")
+
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+
+			msg.data = string(data)
+			messages <- msg
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Not implemented")
+		}
+	}
+}
+
+// TestEmailDigest checks that the digest summarizes the boost activity and is delivered via SMTP.
+func TestEmailDigest(t *testing.T) {
+	host, port, messages := newFakeSMTPServer(t)
+
+	ctx := newTestAppContext(t, "https://hh.ru")
+	ctx.Cfg.Name = "test"
+	ctx.Cfg.Notifications.Email.Host = host
+	ctx.Cfg.Notifications.Email.Port = port
+	ctx.Cfg.Notifications.Email.Security = emailSecurityNone
+	ctx.Cfg.Notifications.Email.Username = "user"
+	ctx.Cfg.Notifications.Email.Password = "password"
+	ctx.Cfg.Notifications.Email.From = "boost@example.com"
+	ctx.Cfg.Notifications.Email.To = []string{"me@example.com"}
+
+	d := newEmailDigest(ctx)
+	nextBoost := time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+
+	for _, ev := range []event{
+		{Kind: eventBoostScheduled, ResumeID: "abc", ResumeTitle: "Go developer", NextBoost: nextBoost},
+		{Kind: eventBoostSucceeded, ResumeID: "abc", ResumeTitle: "Go developer"},
+		{Kind: eventBoostSucceeded, ResumeID: "abc", ResumeTitle: "Go developer"},
+		{Kind: eventBoostFailed, ResumeID: "abc", ResumeTitle: "Go developer", Error: "received an HTTP error: status code 500"},
+		{Kind: eventCaptchaRequired, Text: "HH requires a captcha to log in"},
+	} {
+		if err := d.notify(t.Context(), &ev); err != nil {
+			t.Fatalf("recording event: %v", err)
+		}
+	}
+
+	if err := d.flush(ctx); err != nil {
+		t.Fatalf("sending digest: %v", err)
+	}
+
+	var msg smtpMessage
+	select {
+	case msg = <-messages:
+	case <-time.After(5 * time.Second):
+		t.Fatal("digest has not been delivered")
+	}
+
+	if msg.from != "FROM:<boost@example.com>" || len(msg.to) != 1 || msg.to[0] != "TO:<me@example.com>" {
+		t.Errorf("invalid envelope: from %q, to %q", msg.from, msg.to)
+	}
+
+	if !strings.HasPrefix(msg.auth, "PLAIN") {
+		t.Errorf("invalid authentication: %q", msg.auth)
+	}
+
+	for _, s := range []string{
+		"Subject: Boost digest for test",
+		"Go developer (abc)",
+		"Boosts: 2 succeeded, 1 failed",
+		"status code 500",
+		"Next boost: " + nextBoost.Format(time.DateTime),
+		"HH requires a captcha to log in",
+	} {
+		if !strings.Contains(msg.data, s) {
+			t.Errorf("digest does not contain %q:\n%v", s, msg.data)
+		}
+	}
+
+	// The activity must be reset after a successful delivery
+	body := d.render("test", time.Now())
+	if !strings.Contains(body, "Boosts: 0 succeeded, 0 failed") || strings.Contains(body, "captcha") {
+		t.Errorf("digest has not been reset:\n%v", body)
+	}
+}
+
+// TestEmailDigestConnectionFailure checks that the activity is kept if the digest cannot be sent.
+func TestEmailDigestConnectionFailure(t *testing.T) {
+	ctx := newTestAppContext(t, "https://hh.ru")
+	ctx.Cfg.Notifications.Email.Host = "127.0.0.1"
+	ctx.Cfg.Notifications.Email.Port = 1
+	ctx.Cfg.Notifications.Email.From = "boost@example.com"
+	ctx.Cfg.Notifications.Email.To = []string{"me@example.com"}
+
+	d := newEmailDigest(ctx)
+	_ = d.notify(t.Context(), &event{Kind: eventBoostSucceeded, ResumeID: "abc", ResumeTitle: "Go developer"})
+
+	if err := d.flush(ctx); err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+
+	if body := d.render("test", time.Now()); !strings.Contains(body, "Boosts: 1 succeeded") {
+		t.Errorf("activity has been lost:\n%v", body)
+	}
+}
+
+// TestEmailDigestSlowServer checks that the events are recorded while the digest is being sent,
+// and that they are kept along with the unsent activity if the delivery fails.
+func TestEmailDigestSlowServer(t *testing.T) {
+	l, err := (&net.ListenConfig{}).Listen(t.Context(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	// The server accepts a connection, but never greets the client
+	conns := make(chan net.Conn, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			conns <- conn
+		}
+	}()
+
+	addr := l.Addr().(*net.TCPAddr)
+
+	ctx := newTestAppContext(t, "https://hh.ru")
+	ctx.Cfg.Notifications.Email.Host = addr.IP.String()
+	ctx.Cfg.Notifications.Email.Port = addr.Port
+	ctx.Cfg.Notifications.Email.Security = emailSecurityNone
+	ctx.Cfg.Notifications.Email.From = "boost@example.com"
+	ctx.Cfg.Notifications.Email.To = []string{"me@example.com"}
+
+	d := newEmailDigest(ctx)
+	_ = d.notify(t.Context(), &event{Kind: eventBoostSucceeded, ResumeID: "abc", ResumeTitle: "Go developer"})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.flush(ctx)
+	}()
+
+	var conn net.Conn
+	select {
+	case conn = <-conns:
+	case <-time.After(5 * time.Second):
+		t.Fatal("digest has not connected to the server")
+	}
+
+	recorded := make(chan struct{})
+	go func() {
+		_ = d.notify(t.Context(), &event{Kind: eventBoostFailed, ResumeID: "abc", ResumeTitle: "Go developer", Error: "HTTP 500"})
+		close(recorded)
+	}()
+
+	select {
+	case <-recorded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("event has not been recorded while the digest was being sent")
+	}
+
+	_ = conn.Close()
+
+	if err := <-errCh; err == nil {
+		t.Fatal("expected an error but got nil")
+	}
+
+	if body := d.render("test", time.Now()); !strings.Contains(body, "Boosts: 1 succeeded, 1 failed") {
+		t.Errorf("activity has been lost:\n%v", body)
+	}
+}
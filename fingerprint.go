@@ -0,0 +1,354 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/imroc/req/v3"
+)
+
+// fingerprintAuto picks one of the profiles for every account,
+// based on the account login; the choice is persisted, so that it does not change across restarts.
+const fingerprintAuto = "auto"
+
+// Versions of the impersonated browsers other than Chrome and Edge, whose versions are configurable.
+// These should be kept up with the release history of the browsers.
+const (
+	firefoxVersion = "140.0"
+	safariVersion  = "18.5"
+)
+
+// fingerprintProfile describes a browser that the HTTP client impersonates:
+// everything that is sent to HH should be consistent with it.
+type fingerprintProfile struct {
+	// impersonate sets up the TLS and HTTP/2 fingerprints of the browser engine
+	impersonate func(cl *req.Client) *req.Client
+
+	// userAgent builds the User-Agent from the configured Chrome version
+	userAgent func(chromeVersion int) string
+
+	// brand is the browser name that is sent in the Sec-Ch-Ua header;
+	// it is empty if the browser does not send client hints
+	brand string
+
+	// platform is sent in the Sec-Ch-Ua-Platform header
+	platform string
+
+	// platformVersion and arch are high-entropy client hints,
+	// which are only sent if HH requests them
+	platformVersion string
+	arch            string
+
+	acceptLanguage string
+	acceptEncoding string
+
+	// headerOrder lists the headers in the order that the browser sends them in
+	headerOrder []string
+}
+
+var (
+	chromiumHeaderOrder = []string{
+		"host",
+		"content-length",
+		"sec-ch-ua-platform",
+		"x-xsrftoken",
+		"x-requested-with",
+		"sec-ch-ua",
+		"sec-ch-ua-mobile",
+		"sec-ch-ua-full-version",
+		"sec-ch-ua-arch",
+		"sec-ch-ua-platform-version",
+		"sec-ch-ua-model",
+		"sec-ch-ua-bitness",
+		"sec-ch-ua-wow64",
+		"sec-ch-ua-full-version-list",
+		"sec-ch-ua-form-factors",
+		"upgrade-insecure-requests",
+		"user-agent",
+		"accept",
+		"content-type",
+		"origin",
+		"sec-fetch-site",
+		"sec-fetch-mode",
+		"sec-fetch-user",
+		"sec-fetch-dest",
+		"referer",
+		"accept-encoding",
+		"accept-language",
+		"cookie",
+		"priority",
+	}
+
+	firefoxHeaderOrder = []string{
+		"host",
+		"user-agent",
+		"accept",
+		"accept-language",
+		"accept-encoding",
+		"content-type",
+		"x-xsrftoken",
+		"x-requested-with",
+		"content-length",
+		"origin",
+		"referer",
+		"cookie",
+		"upgrade-insecure-requests",
+		"sec-fetch-dest",
+		"sec-fetch-mode",
+		"sec-fetch-site",
+		"sec-fetch-user",
+		"priority",
+		"te",
+	}
+
+	safariHeaderOrder = []string{
+		"host",
+		"content-type",
+		"accept",
+		"sec-fetch-site",
+		"x-requested-with",
+		"origin",
+		"cookie",
+		"sec-fetch-dest",
+		"x-xsrftoken",
+		"accept-language",
+		"sec-fetch-mode",
+		"user-agent",
+		"referer",
+		"content-length",
+		"accept-encoding",
+		"priority",
+	}
+)
+
+// chromeUserAgent returns a User-Agent builder for Chrome on the specified platform.
+func chromeUserAgent(platform string) func(int) string {
+	return func(chromeVersion int) string {
+		return "Mozilla/5.0 (" + platform + ") AppleWebKit/537.36 (KHTML, like Gecko) Chrome/" +
+			strconv.Itoa(chromeVersion) + ".0.0.0 Safari/537.36"
+	}
+}
+
+// fingerprintProfiles lists the available profiles by their names.
+var fingerprintProfiles = map[string]*fingerprintProfile{
+	"chrome-windows": {
+		impersonate:     (*req.Client).ImpersonateChrome,
+		userAgent:       chromeUserAgent("Windows NT 10.0; Win64; x64"),
+		brand:           "Google Chrome",
+		platform:        "Windows",
+		platformVersion: "19.0.0",
+		arch:            "x86",
+		acceptLanguage:  "en,ru;q=0.9",
+		acceptEncoding:  "gzip, deflate, br, zstd",
+		headerOrder:     chromiumHeaderOrder,
+	},
+	"chrome-macos": {
+		impersonate:     (*req.Client).ImpersonateChrome,
+		userAgent:       chromeUserAgent("Macintosh; Intel Mac OS X 10_15_7"),
+		brand:           "Google Chrome",
+		platform:        "macOS",
+		platformVersion: "15.5.0",
+		arch:            "arm",
+		acceptLanguage:  "en-US,en;q=0.9,ru;q=0.8",
+		acceptEncoding:  "gzip, deflate, br, zstd",
+		headerOrder:     chromiumHeaderOrder,
+	},
+	"chrome-linux": {
+		impersonate:     (*req.Client).ImpersonateChrome,
+		userAgent:       chromeUserAgent("X11; Linux x86_64"),
+		brand:           "Google Chrome",
+		platform:        "Linux",
+		platformVersion: "6.8.0",
+		arch:            "x86",
+		acceptLanguage:  "en-US,en;q=0.9,ru;q=0.8",
+		acceptEncoding:  "gzip, deflate, br, zstd",
+		headerOrder:     chromiumHeaderOrder,
+	},
+	"edge-windows": {
+		impersonate: (*req.Client).ImpersonateChrome,
+		userAgent: func(chromeVersion int) string {
+			v := strconv.Itoa(chromeVersion)
+			return chromeUserAgent("Windows NT 10.0; Win64; x64")(chromeVersion) + " Edg/" + v + ".0.0.0"
+		},
+		brand:           "Microsoft Edge",
+		platform:        "Windows",
+		platformVersion: "19.0.0",
+		arch:            "x86",
+		acceptLanguage:  "en,ru;q=0.9,en-US;q=0.8",
+		acceptEncoding:  "gzip, deflate, br, zstd",
+		headerOrder:     chromiumHeaderOrder,
+	},
+	"firefox-windows": {
+		impersonate: (*req.Client).ImpersonateFirefox,
+		userAgent: func(int) string {
+			return "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:" + firefoxVersion + ") Gecko/20100101 Firefox/" + firefoxVersion
+		},
+		acceptLanguage: "en-US,en;q=0.7,ru;q=0.3",
+		acceptEncoding: "gzip, deflate, br, zstd",
+		headerOrder:    firefoxHeaderOrder,
+	},
+	"safari-macos": {
+		impersonate: (*req.Client).ImpersonateSafari,
+		userAgent: func(int) string {
+			return "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/" +
+				safariVersion + " Safari/605.1.15"
+		},
+		acceptLanguage: "en-US,en;q=0.9,ru;q=0.8",
+		acceptEncoding: "gzip, deflate, br",
+		headerOrder:    safariHeaderOrder,
+	},
+}
+
+// fingerprintProfileNames returns the names of the available profiles in a stable order.
+func fingerprintProfileNames() []string {
+	names := make([]string, 0, len(fingerprintProfiles))
+	for name := range fingerprintProfiles {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// fingerprintSuffix is appended to the cookie jar file name to get the name of the file
+// that keeps the automatically chosen profile.
+const fingerprintSuffix = "fingerprint"
+
+// fingerprintFile is the persistent state of the automatic profile choice.
+type fingerprintFile struct {
+	Profile string `json:"profile"`
+}
+
+// resolveFingerprintProfile returns the name of the profile that the account uses.
+//
+// The automatic choice is made by the login and then persisted next to the cookie jar,
+// so that an account keeps its browser even if the list of the profiles changes.
+func resolveFingerprintProfile(ctx *AppContext) string {
+	cfg := &ctx.Cfg
+	if cfg.Fingerprint.Profile != fingerprintAuto {
+		return cfg.Fingerprint.Profile
+	}
+
+	fileName := ""
+	if cfg.CookieJarFileName != "" {
+		fileName = addFileNameSuffix(cfg.CookieJarFileName, fingerprintSuffix)
+	}
+
+	name, err := loadFingerprintProfile(fileName)
+	if err != nil {
+		ctx.Log.Warn("failed to load the fingerprint profile, picking a new one", "error", err)
+	}
+
+	if _, ok := fingerprintProfiles[name]; ok {
+		return name
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(cfg.Login))
+
+	names := fingerprintProfileNames()
+	name = names[h.Sum32()%uint32(len(names))]
+
+	err = saveFingerprintProfile(fileName, name)
+	if err != nil {
+		ctx.Log.Warn("failed to save the fingerprint profile", "error", err)
+	}
+
+	return name
+}
+
+// loadFingerprintProfile reads the automatically chosen profile, if it has been persisted.
+func loadFingerprintProfile(fileName string) (string, error) {
+	if fileName == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("reading fingerprint file: %w", err)
+	}
+
+	var state fingerprintFile
+	err = json.Unmarshal(data, &state)
+	if err != nil {
+		return "", fmt.Errorf("decoding fingerprint file: %w", err)
+	}
+
+	return state.Profile, nil
+}
+
+// saveFingerprintProfile persists the automatically chosen profile.
+func saveFingerprintProfile(fileName, name string) error {
+	if fileName == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(&fingerprintFile{Profile: name}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding fingerprint: %w", err)
+	}
+
+	err = os.WriteFile(fileName, data, 0o600)
+	if err != nil {
+		return fmt.Errorf("writing fingerprint file: %w", err)
+	}
+
+	return nil
+}
+
+// applyFingerprint makes the client impersonate the browser of the profile.
+// The config must have been validated beforehand, so that the profile is known to exist.
+func applyFingerprint(ctx *AppContext, cl *req.Client) {
+	cfg := &ctx.Cfg
+	profile := fingerprintProfiles[resolveFingerprintProfile(ctx)]
+
+	// The transport wrappers run in the reverse order of their registration,
+	// and the impersonation installs a wrapper that forces its own header order on every request.
+	// Registering ours first makes it run last, so that our order is the one that reaches the wire.
+	cl.SetCommonHeaderOrder(profile.headerOrder...)
+	profile.impersonate(cl)
+
+	acceptLanguage := profile.acceptLanguage
+	if cfg.Fingerprint.AcceptLanguage != "" {
+		acceptLanguage = cfg.Fingerprint.AcceptLanguage
+	}
+
+	cl.SetCommonHeaders(map[string]string{
+		"User-Agent": profile.userAgent(cfg.ChromeVersion),
+
+		"Sec-Fetch-Site": "same-origin",
+		"Sec-Fetch-User": "?1",
+
+		"Accept-Language": acceptLanguage,
+		"Accept-Encoding": profile.acceptEncoding,
+	})
+
+	// The Chrome version may change at runtime, and the User-Agent has to follow it
+	cl.WrapRoundTripFunc(func(rt req.RoundTripper) req.RoundTripFunc {
+		return func(r *req.Request) (*req.Response, error) {
+			r.Headers.Set("User-Agent", profile.userAgent(currentChromeVersion(ctx)))
+			return rt.RoundTrip(r)
+		}
+	})
+
+	if profile.brand != "" {
+		cl.SetCommonHeaders(map[string]string{
+			"Sec-Ch-Ua-Platform": strconv.Quote(profile.platform),
+			"Sec-Ch-Ua-Mobile":   "?0",
+		})
+
+		newClientHints(ctx, profile).instrument(cl)
+	}
+
+	// These are set by the impersonation and browsers do not normally send them while interacting with HH,
+	// so we have to delete them
+	cl.Headers.Del("Pragma")
+	cl.Headers.Del("Cache-Control")
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestFingerprintProfiles checks that the headers are consistent with the impersonated browser.
+func TestFingerprintProfiles(t *testing.T) {
+	tests := []struct {
+		profile   string
+		userAgent string
+		brand     string
+		platform  string
+	}{
+		{"chrome-windows", "Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/147.0.0.0", "Google Chrome", `"Windows"`},
+		{"chrome-macos", "Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/147.0.0.0", "Google Chrome", `"macOS"`},
+		{"chrome-linux", "X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/147.0.0.0", "Google Chrome", `"Linux"`},
+		{"edge-windows", "Chrome/147.0.0.0 Safari/537.36 Edg/147.0.0.0", "Microsoft Edge", `"Windows"`},
+		{"firefox-windows", "Gecko/20100101 Firefox/" + firefoxVersion, "", ""},
+		{"safari-macos", "Version/" + safariVersion + " Safari/605.1.15", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.profile, func(t *testing.T) {
+			var headers http.Header
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				headers = r.Header.Clone()
+			}))
+			t.Cleanup(srv.Close)
+
+			ctx := newTestAppContext(t, srv.URL)
+			ctx.Cfg.ChromeVersion = 147
+			ctx.Cfg.Fingerprint.Profile = test.profile
+
+			if _, err := createHTTPClient(ctx).R().Get(srv.URL); err != nil {
+				t.Fatalf("sending request: %v", err)
+			}
+
+			if ua := headers.Get("User-Agent"); !strings.Contains(ua, test.userAgent) {
+				t.Errorf("invalid user agent: got %q, expected it to contain %q", ua, test.userAgent)
+			}
+
+			if brand := headers.Get("Sec-Ch-Ua"); !strings.Contains(brand, test.brand) || (test.brand == "") != (brand == "") {
+				t.Errorf("invalid brands: got %q, expected %q", brand, test.brand)
+			}
+
+			if platform := headers.Get("Sec-Ch-Ua-Platform"); platform != test.platform {
+				t.Errorf("invalid platform: got %q, expected %q", platform, test.platform)
+			}
+
+			if headers.Get("Accept-Language") == "" || headers.Get("Pragma") != "" {
+				t.Errorf("invalid headers: %v", headers)
+			}
+		})
+	}
+}
+
+// readHeaderOrder accepts a single HTTP/1.1 request on the listener
+// and returns the names of its headers in the order they have been sent in.
+func readHeaderOrder(t *testing.T, ln net.Listener) <-chan []string {
+	t.Helper()
+
+	ch := make(chan []string, 1)
+
+	go func() {
+		defer close(ch)
+
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		names := []string{}
+		r := bufio.NewReader(conn)
+
+		// Skip the request line
+		if _, err := r.ReadString('\n'); err != nil {
+			return
+		}
+
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			line = strings.TrimRight(line, "\r\n")
+			if line == "" {
+				break
+			}
+
+			name, _, _ := strings.Cut(line, ":")
+			names = append(names, strings.ToLower(name))
+		}
+
+		_, _ = conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\nConnection: close\r\n\r\n"))
+		ch <- names
+	}()
+
+	return ch
+}
+
+// TestFingerprintHeaderOrder checks that the headers are sent in the order of the profile
+// rather than in the order of the impersonated browser engine.
+func TestFingerprintHeaderOrder(t *testing.T) {
+	for _, name := range fingerprintProfileNames() {
+		t.Run(name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("listening: %v", err)
+			}
+			t.Cleanup(func() { _ = ln.Close() })
+
+			namesCh := readHeaderOrder(t, ln)
+			url := "http://" + ln.Addr().String()
+
+			ctx := newTestAppContext(t, url)
+			ctx.Cfg.ChromeVersion = 147
+			ctx.Cfg.Fingerprint.Profile = name
+
+			r := createHTTPClient(ctx).R()
+			r.SetHeader("X-Xsrftoken", "xsrf-token")
+			r.SetHeader("X-Requested-With", "XMLHttpRequest")
+			r.SetHeader("Referer", url+"/")
+
+			if _, err := r.Get(url); err != nil {
+				t.Fatalf("sending request: %v", err)
+			}
+
+			names := <-namesCh
+			if len(names) == 0 {
+				t.Fatal("request has not been received")
+			}
+
+			// The headers that the profile does not know about are not checked
+			order := fingerprintProfiles[name].headerOrder
+			known := slices.DeleteFunc(slices.Clone(names), func(name string) bool {
+				return !slices.Contains(order, name)
+			})
+
+			sorted := slices.SortedFunc(slices.Values(known), func(a, b string) int {
+				return slices.Index(order, a) - slices.Index(order, b)
+			})
+
+			if !slices.Equal(known, sorted) {
+				t.Errorf("invalid header order: got %v, expected %v", known, sorted)
+			}
+		})
+	}
+}
+
+// TestAutoFingerprintProfile checks that the automatic profile is stable for an account,
+// and that it is kept even if the choice by the login would be different.
+func TestAutoFingerprintProfile(t *testing.T) {
+	ctx := newTestAppContext(t, "http://127.0.0.1")
+	ctx.Cfg.Fingerprint.Profile = fingerprintAuto
+	ctx.Cfg.Login = "alice@example.com"
+
+	profile := resolveFingerprintProfile(ctx)
+	if _, ok := fingerprintProfiles[profile]; !ok {
+		t.Fatalf("unknown profile: %q", profile)
+	}
+
+	for range 10 {
+		if p := resolveFingerprintProfile(ctx); p != profile {
+			t.Fatalf("profile has changed: got %q, expected %q", p, profile)
+		}
+	}
+
+	// Different accounts may get different profiles
+	seen := map[string]struct{}{}
+	for _, login := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		ctx.Cfg.Login = login
+		seen[resolveFingerprintProfile(ctx)] = struct{}{}
+	}
+
+	if len(seen) < 2 {
+		t.Errorf("all accounts got the same profile: %v", seen)
+	}
+
+	// The persisted choice wins over the login
+	ctx.Cfg.CookieJarFileName = filepath.Join(t.TempDir(), "cookies.json")
+	ctx.Cfg.Login = "a"
+	profile = resolveFingerprintProfile(ctx)
+
+	for _, login := range []string{"b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		ctx.Cfg.Login = login
+		if p := resolveFingerprintProfile(ctx); p != profile {
+			t.Fatalf("persisted profile has not been used: got %q, expected %q", p, profile)
+		}
+	}
+
+	// An unknown profile, e.g. one that has been removed in an upgrade, is replaced
+	fileName := filepath.Join(filepath.Dir(ctx.Cfg.CookieJarFileName), "cookies.fingerprint.json")
+	if err := os.WriteFile(fileName, []byte(`{"profile": "netscape-windows"}`), 0o600); err != nil {
+		t.Fatalf("writing fingerprint file: %v", err)
+	}
+
+	if p := resolveFingerprintProfile(ctx); p == "netscape-windows" {
+		t.Fatalf("unknown profile has been used: %q", p)
+	}
+
+	if data, err := os.ReadFile(fileName); err != nil || strings.Contains(string(data), "netscape-windows") {
+		t.Errorf("fingerprint file has not been updated: %q (%v)", data, err)
+	}
+}
@@ -0,0 +1,551 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/imroc/req/v3"
+)
+
+// harRedacted replaces the sensitive values in the recorded traffic.
+const harRedacted = "REDACTED"
+
+// The HAR file is kept valid after every entry: new entries are written over the trailer,
+// which is then appended again.
+const harTrailer = "]}}\n"
+
+// harSensitiveHeaders lists the (lowercase) headers whose values are redacted.
+// The x-gib-* headers mirror the values of the corresponding cookies.
+var harSensitiveHeaders = map[string]struct{}{
+	"cookie":              {},
+	"set-cookie":          {},
+	"authorization":       {},
+	"proxy-authorization": {},
+	"x-xsrftoken":         {},
+	"x-gib-gsscgib-w-hh":  {},
+	"x-gib-fgsscgib-w-hh": {},
+}
+
+// harSensitiveParams lists the query and form parameters whose values are redacted.
+var harSensitiveParams = map[string]struct{}{
+	"password": {},
+	"code":     {},
+	"_xsrf":    {},
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Params   []harNameValue `json:"params,omitempty"`
+	Text     string         `json:"text,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+// harRecorder records every HTTP exchange with HH into a HAR file,
+// which is rotated once it grows too large.
+type harRecorder struct {
+	fileName   string
+	maxSize    int64
+	maxBackups int
+	redact     bool
+	log        *slog.Logger
+
+	// secrets are replaced everywhere in the recorded traffic: the password and the XSRF tokens seen so far
+	secrets map[string]struct{}
+
+	mu sync.Mutex
+}
+
+// newHARRecorder creates a HAR recorder from the config.
+// If HAR recording is disabled, it returns nil.
+func newHARRecorder(ctx *AppContext) *harRecorder {
+	if ctx.Cfg.HAR.FileName == "" {
+		return nil
+	}
+
+	hr := &harRecorder{
+		fileName:   ctx.Cfg.HAR.FileName,
+		maxSize:    int64(ctx.Cfg.HAR.MaxSizeMB) << 20,
+		maxBackups: ctx.Cfg.HAR.MaxBackups,
+		redact:     ctx.Cfg.HAR.Redact,
+		log:        ctx.Log,
+		secrets:    map[string]struct{}{},
+	}
+
+	if ctx.Cfg.Password != "" {
+		hr.secrets[ctx.Cfg.Password] = struct{}{}
+	}
+
+	return hr
+}
+
+// instrument records the traffic of the client.
+// The recording happens at the transport level, so that redirects and the cookies from the jar are recorded too.
+func (hr *harRecorder) instrument(cl *req.Client) {
+	if hr == nil {
+		return
+	}
+
+	cl.Transport.WrapRoundTripFunc(func(rt http.RoundTripper) req.HttpRoundTripFunc {
+		return func(r *http.Request) (*http.Response, error) {
+			start := time.Now()
+
+			resp, err := rt.RoundTrip(r)
+			wait := time.Since(start)
+
+			var body []byte
+			if err == nil {
+				body, err = io.ReadAll(resp.Body)
+				_ = resp.Body.Close()
+				resp.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			entry := hr.entry(r, resp, body, err, start, wait, time.Since(start)-wait)
+			if recordErr := hr.append(entry); recordErr != nil {
+				hr.log.Warn("failed to record HTTP traffic", "error", recordErr)
+			}
+
+			return resp, err
+		}
+	})
+}
+
+// entry builds a HAR entry for an HTTP exchange.
+func (hr *harRecorder) entry(r *http.Request, resp *http.Response, body []byte, err error, start time.Time, wait, receive time.Duration) *harEntry {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	hr.collectSecrets(r, resp)
+
+	entry := &harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            durationMillis(wait + receive),
+		Request:         hr.request(r),
+		Timings: harTimings{
+			Wait:    durationMillis(wait),
+			Receive: durationMillis(receive),
+		},
+	}
+
+	if err != nil {
+		entry.Comment = hr.scrub(err.Error())
+	}
+
+	if resp == nil {
+		entry.Response = harResponse{
+			Cookies:     []harCookie{},
+			Headers:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+
+		return entry
+	}
+
+	entry.Request.HTTPVersion = resp.Proto
+	entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode))),
+		HTTPVersion: resp.Proto,
+		Cookies:     hr.cookies(resp.Cookies()),
+		Headers:     hr.headers(resp.Header),
+		Content:     hr.content(resp.Header.Get("Content-Type"), body),
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+
+	return entry
+}
+
+// collectSecrets remembers the XSRF tokens that are sent or received, so that they can be redacted
+// from the bodies too. The caller must hold mu.
+func (hr *harRecorder) collectSecrets(r *http.Request, resp *http.Response) {
+	if !hr.redact {
+		return
+	}
+
+	values := []string{r.Header.Get("X-Xsrftoken")}
+	if c, err := r.Cookie("_xsrf"); err == nil {
+		values = append(values, c.Value)
+	}
+
+	if resp != nil {
+		for _, c := range resp.Cookies() {
+			if c.Name == "_xsrf" {
+				values = append(values, c.Value)
+			}
+		}
+	}
+
+	for _, v := range values {
+		if v != "" {
+			hr.secrets[v] = struct{}{}
+		}
+	}
+}
+
+// scrub replaces the known secrets in a string. The caller must hold mu.
+func (hr *harRecorder) scrub(s string) string {
+	if !hr.redact {
+		return s
+	}
+
+	for secret := range hr.secrets {
+		s = strings.ReplaceAll(s, secret, harRedacted)
+		s = strings.ReplaceAll(s, url.QueryEscape(secret), harRedacted)
+	}
+
+	return s
+}
+
+// request converts an HTTP request. The caller must hold mu.
+func (hr *harRecorder) request(r *http.Request) harRequest {
+	hreq := harRequest{
+		Method:      r.Method,
+		URL:         hr.scrub(r.URL.String()),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     hr.cookies(r.Cookies()),
+		Headers:     hr.headers(r.Header),
+		QueryString: hr.params(r.URL.Query()),
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+
+	if r.GetBody == nil || r.ContentLength == 0 {
+		return hreq
+	}
+
+	rc, err := r.GetBody()
+	if err != nil {
+		return hreq
+	}
+	defer func() { _ = rc.Close() }()
+
+	body, err := io.ReadAll(rc)
+	if err != nil {
+		return hreq
+	}
+
+	hreq.BodySize = len(body)
+	hreq.PostData = hr.postData(r.Header.Get("Content-Type"), body)
+
+	return hreq
+}
+
+// postData converts a request body. Form bodies are recorded as parameters, so that they can be redacted.
+// The caller must hold mu.
+func (hr *harRecorder) postData(contentType string, body []byte) *harPostData {
+	pd := &harPostData{MimeType: contentType}
+
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err == nil {
+			pd.Params = hr.params(form)
+			return pd
+		}
+	case "multipart/form-data":
+		form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(int64(len(body)))
+		if err == nil {
+			pd.Params = hr.params(form.Value)
+			_ = form.RemoveAll()
+
+			return pd
+		}
+	}
+
+	pd.Text = hr.scrub(string(body))
+	return pd
+}
+
+// params converts query or form parameters, redacting the sensitive ones. The caller must hold mu.
+func (hr *harRecorder) params(values url.Values) []harNameValue {
+	res := []harNameValue{}
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		for _, v := range values[name] {
+			if _, ok := harSensitiveParams[strings.ToLower(name)]; ok && hr.redact {
+				v = harRedacted
+			}
+
+			res = append(res, harNameValue{Name: name, Value: hr.scrub(v)})
+		}
+	}
+
+	return res
+}
+
+// headers converts HTTP headers, redacting the sensitive ones. The caller must hold mu.
+// The internal keys that req uses to pass the header order to its transport are never sent, so they are skipped.
+func (hr *harRecorder) headers(h http.Header) []harNameValue {
+	res := []harNameValue{}
+	for _, name := range slices.Sorted(maps.Keys(h)) {
+		if name == req.HeaderOderKey || name == req.PseudoHeaderOderKey {
+			continue
+		}
+
+		for _, v := range h[name] {
+			if _, ok := harSensitiveHeaders[strings.ToLower(name)]; ok && hr.redact {
+				v = harRedacted
+			}
+
+			res = append(res, harNameValue{Name: name, Value: v})
+		}
+	}
+
+	return res
+}
+
+// cookies converts HTTP cookies, redacting their values. The caller must hold mu.
+func (hr *harRecorder) cookies(cookies []*http.Cookie) []harCookie {
+	res := []harCookie{}
+	for _, c := range cookies {
+		hc := harCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+
+		if !c.Expires.IsZero() {
+			hc.Expires = c.Expires.Format(time.RFC3339)
+		}
+
+		if hr.redact {
+			hc.Value = harRedacted
+		}
+
+		res = append(res, hc)
+	}
+
+	return res
+}
+
+// content converts a response body. Binary bodies are base64-encoded. The caller must hold mu.
+func (hr *harRecorder) content(contentType string, body []byte) harContent {
+	c := harContent{
+		Size:     len(body),
+		MimeType: contentType,
+	}
+
+	if utf8.Valid(body) {
+		c.Text = hr.scrub(string(body))
+	} else {
+		c.Text = base64.StdEncoding.EncodeToString(body)
+		c.Encoding = "base64"
+	}
+
+	return c
+}
+
+// append writes an entry to the HAR file and rotates the file if it has grown too large.
+func (hr *harRecorder) append(entry *harEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encoding HAR entry: %w", err)
+	}
+
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	f, offset, empty, err := hr.open()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if offset == 0 {
+		buf.WriteString(harHeader())
+	} else if !empty {
+		buf.WriteByte(',')
+	}
+
+	buf.Write(data)
+	buf.WriteString(harTrailer)
+
+	_, err = f.WriteAt(buf.Bytes(), offset)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("writing HAR file: %w", err)
+	}
+
+	if offset+int64(buf.Len()) >= hr.maxSize {
+		return hr.rotate()
+	}
+
+	return nil
+}
+
+// open opens the HAR file and returns the offset at which the next entry should be written,
+// and whether the file contains no entries yet. A file that is not a valid HAR file is rotated.
+// The caller must hold mu.
+func (hr *harRecorder) open() (*os.File, int64, bool, error) {
+	for rotated := false; ; rotated = true {
+		f, err := os.OpenFile(hr.fileName, os.O_RDWR|os.O_CREATE, 0o600)
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("opening HAR file: %w", err)
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, 0, false, fmt.Errorf("reading HAR file: %w", err)
+		}
+
+		size := info.Size()
+		if size == 0 {
+			return f, 0, true, nil
+		}
+
+		// The file must end with the trailer, which is preceded either by the opening bracket or by an entry
+		tail := make([]byte, len(harTrailer)+1)
+		if size >= int64(len(harHeader())+len(harTrailer)) {
+			_, err = f.ReadAt(tail, size-int64(len(tail)))
+		} else {
+			err = errors.New("file is too short")
+		}
+
+		if err == nil && string(tail[1:]) == harTrailer {
+			return f, size - int64(len(harTrailer)), tail[0] == '[', nil
+		}
+
+		_ = f.Close()
+		if rotated {
+			return nil, 0, false, errors.New("invalid HAR file")
+		}
+
+		hr.log.Warn("HAR file is corrupted, rotating it", "file_name", hr.fileName)
+
+		err = hr.rotate()
+		if err != nil {
+			return nil, 0, false, err
+		}
+	}
+}
+
+// rotate renames the HAR file to a backup, shifting the older backups and removing the oldest one:
+// "traffic.har" becomes "traffic.1.har", which becomes "traffic.2.har", and so on.
+// The caller must hold mu.
+func (hr *harRecorder) rotate() error {
+	backup := func(n int) string {
+		return addFileNameSuffix(hr.fileName, strconv.Itoa(n))
+	}
+
+	oldest := hr.fileName
+	if hr.maxBackups > 0 {
+		oldest = backup(hr.maxBackups)
+	}
+
+	err := os.Remove(oldest)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("removing HAR file backup: %w", err)
+	}
+
+	for n := hr.maxBackups; n > 0; n-- {
+		src := hr.fileName
+		if n > 1 {
+			src = backup(n - 1)
+		}
+
+		err = os.Rename(src, backup(n))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("rotating HAR file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// harHeader returns the beginning of a HAR file, up to the opening bracket of the entries.
+func harHeader() string {
+	v, _ := json.Marshal(version)
+	return `{"log":{"version":"1.2","creator":{"name":"hh-resume-auto-boost","version":` + string(v) + `},"entries":[`
+}
+
+// durationMillis converts a duration to fractional milliseconds, which HAR uses.
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
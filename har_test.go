@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/imroc/req/v3"
+)
+
+// readHARFile decodes a HAR file and returns its entries.
+func readHARFile(t *testing.T, fileName string) []harEntry {
+	t.Helper()
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("reading HAR file: %v", err)
+	}
+
+	var har struct {
+		Log struct {
+			Version string     `json:"version"`
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("decoding HAR file: %v", err)
+	}
+
+	if har.Log.Version != "1.2" {
+		t.Errorf("invalid HAR version: got %q, expected %q", har.Log.Version, "1.2")
+	}
+
+	return har.Log.Entries
+}
+
+// TestHARRecording checks that the traffic is recorded with the credentials redacted,
+// and that the recording continues in the same file after a restart.
+func TestHARRecording(t *testing.T) {
+	const xsrf = "xsrf-token-1234567890"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "_xsrf", Value: xsrf, Path: "/"})
+			_, _ = w.Write([]byte(`{"xsrfToken": "` + xsrf + `"}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	harFileName := filepath.Join(dir, "traffic.har")
+
+	newContext := func() *AppContext {
+		ctx := newTestAppContext(t, srv.URL)
+		ctx.Cfg.CookieJarFileName = filepath.Join(dir, "cookies.json")
+		ctx.Cfg.HAR.FileName = harFileName
+
+		return ctx
+	}
+
+	ctx := newContext()
+	cl := createHTTPClient(ctx)
+
+	if _, err := cl.R().Get(srv.URL + "/login"); err != nil {
+		t.Fatalf("sending request: %v", err)
+	}
+
+	r := cl.R()
+	r.SetHeader("X-Xsrftoken", xsrf)
+	r.EnableForceMultipart()
+	r.SetFormData(map[string]string{
+		"username": ctx.Cfg.Login,
+		"password": ctx.Cfg.Password,
+	})
+
+	if _, err := r.Post(srv.URL + "/account/login"); err != nil {
+		t.Fatalf("sending request: %v", err)
+	}
+
+	if entries := readHARFile(t, harFileName); len(entries) != 2 {
+		t.Fatalf("invalid number of entries: got %v, expected 2", len(entries))
+	}
+
+	// After a restart, the entries are appended to the same file
+	if _, err := createHTTPClient(newContext()).R().Get(srv.URL + "/applicant/resumes?_xsrf=" + xsrf); err != nil {
+		t.Fatalf("sending request: %v", err)
+	}
+
+	entries := readHARFile(t, harFileName)
+	if len(entries) != 3 {
+		t.Fatalf("invalid number of entries: got %v, expected 3", len(entries))
+	}
+
+	data, err := os.ReadFile(harFileName)
+	if err != nil {
+		t.Fatalf("reading HAR file: %v", err)
+	}
+
+	for _, secret := range []string{xsrf, ctx.Cfg.Password} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("secret leaks into the HAR file: %q", secret)
+		}
+	}
+
+	login := entries[1].Request
+	if login.Method != http.MethodPost || !strings.HasSuffix(login.URL, "/account/login") {
+		t.Errorf("invalid request: %v %v", login.Method, login.URL)
+	}
+
+	params := map[string]string{}
+	if login.PostData != nil {
+		for _, p := range login.PostData.Params {
+			params[p.Name] = p.Value
+		}
+	}
+
+	if params["username"] != ctx.Cfg.Login || params["password"] != harRedacted {
+		t.Errorf("invalid form parameters: %v", params)
+	}
+
+	for _, h := range login.Headers {
+		if h.Name == req.HeaderOderKey || h.Name == req.PseudoHeaderOderKey {
+			t.Errorf("internal header has been recorded: %v", h.Name)
+		}
+	}
+
+	if len(login.Cookies) != 1 || login.Cookies[0].Name != "_xsrf" {
+		t.Errorf("invalid cookies: %v", login.Cookies)
+	}
+
+	if resp := entries[0].Response; resp.Status != http.StatusOK || !strings.Contains(resp.Content.Text, "xsrfToken") {
+		t.Errorf("invalid response: %v %q", resp.Status, resp.Content.Text)
+	}
+}
+
+// TestHARRotation checks that the HAR file is rotated once it grows too large,
+// and that only the configured number of backups is kept.
+func TestHARRotation(t *testing.T) {
+	body := strings.Repeat("a", 400<<10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	harFileName := filepath.Join(t.TempDir(), "traffic.har")
+
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Cfg.HAR.FileName = harFileName
+	ctx.Cfg.HAR.MaxSizeMB = 1
+	ctx.Cfg.HAR.MaxBackups = 2
+
+	cl := createHTTPClient(ctx)
+	for range 10 {
+		if _, err := cl.R().Get(srv.URL); err != nil {
+			t.Fatalf("sending request: %v", err)
+		}
+	}
+
+	// Every file holds 3 entries, the last one being written over the limit
+	for _, fileName := range []string{
+		filepath.Join(filepath.Dir(harFileName), "traffic.1.har"),
+		filepath.Join(filepath.Dir(harFileName), "traffic.2.har"),
+	} {
+		if entries := readHARFile(t, fileName); len(entries) != 3 {
+			t.Errorf("invalid number of entries in %v: got %v, expected 3", filepath.Base(fileName), len(entries))
+		}
+	}
+
+	if entries := readHARFile(t, harFileName); len(entries) != 1 {
+		t.Errorf("invalid number of entries: got %v, expected 1", len(entries))
+	}
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(harFileName), "traffic.3.har")); !os.IsNotExist(err) {
+		t.Errorf("too many backups have been kept: %v", err)
+	}
+}
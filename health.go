@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// livenessTimeout is how long the liveness check waits for a scheduler
+// before reporting it as deadlocked.
+const livenessTimeout = 2 * time.Second
+
+// appHealth aggregates the health of all accounts and serves the Kubernetes probe endpoints.
+type appHealth struct {
+	accounts []*accountHealth
+	mu       sync.Mutex
+}
+
+func newAppHealth() *appHealth {
+	return &appHealth{}
+}
+
+// account creates and tracks the health of a new account.
+func (h *appHealth) account(name string) *accountHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ah := &accountHealth{name: name}
+	h.accounts = append(h.accounts, ah)
+
+	return ah
+}
+
+// register adds the /healthz and /readyz endpoints to the mux.
+func (h *appHealth) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", h.handleCheck((*accountHealth).alive))
+	mux.HandleFunc("GET /readyz", h.handleCheck((*accountHealth).ready))
+}
+
+// handleCheck returns an HTTP handler that runs the check against every account
+// and responds with 503 if any of them fails.
+func (h *appHealth) handleCheck(check func(ah *accountHealth) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		h.mu.Lock()
+		accounts := h.accounts
+		h.mu.Unlock()
+
+		errs := make([]error, 0, len(accounts))
+		for _, ah := range accounts {
+			if err := check(ah); err != nil {
+				errs = append(errs, fmt.Errorf("account %q: %w", ah.name, err))
+			}
+		}
+
+		if err := errors.Join(errs...); err != nil {
+			writeJSONError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}
+}
+
+// accountHealth tracks the health of a single account.
+type accountHealth struct {
+	name string
+
+	// mu guards the fields below
+	mu sync.Mutex
+
+	// sched is nil until the scheduler of the account has been created
+	sched *resumeScheduler
+
+	discovered       bool
+	discoveryErr     error
+	discoveryStopped bool
+
+	authErr error
+}
+
+// setScheduler attaches the scheduler that is checked for deadlocks.
+func (ah *accountHealth) setScheduler(sched *resumeScheduler) {
+	ah.mu.Lock()
+	defer ah.mu.Unlock()
+
+	ah.sched = sched
+}
+
+// recordDiscovery records the result of a discovery pass.
+func (ah *accountHealth) recordDiscovery(err error) {
+	ah.mu.Lock()
+	defer ah.mu.Unlock()
+
+	ah.discoveryErr = err
+	if err == nil {
+		ah.discovered = true
+	}
+}
+
+// stopDiscovery records that the discovery has given up after too many failures.
+func (ah *accountHealth) stopDiscovery() {
+	ah.mu.Lock()
+	defer ah.mu.Unlock()
+
+	ah.discoveryStopped = true
+}
+
+// recordAuthentication records the result of an authentication attempt.
+func (ah *accountHealth) recordAuthentication(err error) {
+	ah.mu.Lock()
+	defer ah.mu.Unlock()
+
+	ah.authErr = err
+}
+
+// alive checks that the scheduler of the account is not deadlocked.
+func (ah *accountHealth) alive() error {
+	ah.mu.Lock()
+	sched := ah.sched
+	ah.mu.Unlock()
+
+	if sched != nil && !sched.responsive(livenessTimeout) {
+		return errors.New("scheduler is not responding")
+	}
+
+	return nil
+}
+
+// ready checks that the last discovery has succeeded and that the session is authenticated.
+func (ah *accountHealth) ready() error {
+	ah.mu.Lock()
+	defer ah.mu.Unlock()
+
+	switch {
+	case ah.discoveryStopped:
+		return errors.New("resume discovery has been stopped due to consecutive failures")
+	case ah.authErr != nil:
+		return fmt.Errorf("not authenticated: %w", ah.authErr)
+	case ah.discoveryErr != nil:
+		return fmt.Errorf("last resume discovery has failed: %w", ah.discoveryErr)
+	case !ah.discovered:
+		return errors.New("resumes have not been discovered yet")
+	}
+
+	return nil
+}
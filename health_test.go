@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHealthEndpoints checks the transitions of the liveness and readiness checks.
+func TestHealthEndpoints(t *testing.T) {
+	health := newAppHealth()
+	ah := health.account("test")
+
+	mux := http.NewServeMux()
+	health.register(mux)
+
+	probe := func(path string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	sched := newResumeScheduler(nil, nil, nil, nil)
+	ah.setScheduler(sched)
+
+	steps := []struct {
+		name      string
+		fn        func()
+		readiness int
+	}{
+		{"initial state", func() {}, http.StatusServiceUnavailable},
+		{"successful discovery", func() { ah.recordDiscovery(nil) }, http.StatusOK},
+		{"failed authentication", func() { ah.recordAuthentication(errors.New("invalid password")) }, http.StatusServiceUnavailable},
+		{"successful authentication", func() { ah.recordAuthentication(nil) }, http.StatusOK},
+		{"failed discovery", func() { ah.recordDiscovery(errors.New("HTTP 500")) }, http.StatusServiceUnavailable},
+		{"recovered discovery", func() { ah.recordDiscovery(nil) }, http.StatusOK},
+		{"stopped discovery", ah.stopDiscovery, http.StatusServiceUnavailable},
+	}
+
+	for _, step := range steps {
+		step.fn()
+
+		if code := probe("/readyz"); code != step.readiness {
+			t.Errorf("invalid readiness after %v: got %v, expected %v", step.name, code, step.readiness)
+		}
+
+		if code := probe("/healthz"); code != http.StatusOK {
+			t.Errorf("invalid liveness after %v: got %v, expected %v", step.name, code, http.StatusOK)
+		}
+	}
+
+	// Simulate a deadlocked scheduler
+	sched.resumeMu.Lock()
+	defer sched.resumeMu.Unlock()
+
+	if code := probe("/healthz"); code != http.StatusServiceUnavailable {
+		t.Errorf("invalid liveness of a deadlocked scheduler: got %v, expected %v", code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxHolidayStreak limits the number of consecutive holidays that are skipped when looking for a workday.
+const maxHolidayStreak = 366
+
+// yearlyHoliday is a holiday that repeats every year on the same date, e.g. the New Year holidays.
+type yearlyHoliday struct {
+	month time.Month
+	day   int
+
+	// days is the duration of the holiday, in days
+	days int
+
+	// The holiday is observed from the first year to the last one (inclusive).
+	// lastYear is 0 if the holiday repeats forever
+	firstYear int
+	lastYear  int
+}
+
+// holidayCalendar is a set of days when the boosts are skipped or reduced.
+type holidayCalendar struct {
+	// fileName is empty if the calendar consists of the config dates only
+	fileName       string
+	reloadInterval time.Duration
+	clock          clock
+
+	loc *time.Location
+
+	// dates are the holidays from the config, which are merged with the ones from the file
+	dates []time.Time
+
+	// These are guarded by mu
+	mu      sync.Mutex
+	days    map[string]struct{}
+	yearly  []yearlyHoliday
+	modTime time.Time
+}
+
+// newHolidayCalendar loads the holiday calendar that is specified in the config.
+// If there are no holidays configured, it returns nil.
+func newHolidayCalendar(cfg *Config, clk clock) (*holidayCalendar, error) {
+	if cfg.Holidays.FileName == "" && len(cfg.Holidays.Dates) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	loc, err := loadTimeZone(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	hc := &holidayCalendar{
+		reloadInterval: cfg.Holidays.ReloadInterval,
+		clock:          clk,
+		loc:            loc,
+	}
+
+	if cfg.Holidays.FileName != "" {
+		hc.fileName = filepath.Clean(cfg.Holidays.FileName)
+	}
+
+	hc.dates, err = parseHolidayDates(cfg.Holidays.Dates, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = hc.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return hc, nil
+}
+
+// parseHolidayDates parses a list of dates in the YYYY-MM-DD format.
+func parseHolidayDates(dates []string, loc *time.Location) ([]time.Time, error) {
+	parsed := make([]time.Time, 0, len(dates))
+
+	for _, date := range dates {
+		t, err := time.ParseInLocation(time.DateOnly, strings.TrimSpace(date), loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday date %q (must be YYYY-MM-DD)", date)
+		}
+
+		parsed = append(parsed, t)
+	}
+
+	return parsed, nil
+}
+
+// reload re-reads the calendar file if it has been modified since the last load.
+// If the file cannot be parsed, the previously loaded holidays are kept.
+func (hc *holidayCalendar) reload() (bool, error) {
+	days := map[string]struct{}{}
+	for _, date := range hc.dates {
+		days[date.Format(time.DateOnly)] = struct{}{}
+	}
+
+	var (
+		yearly  []yearlyHoliday
+		modTime time.Time
+	)
+
+	if hc.fileName != "" {
+		info, err := os.Stat(hc.fileName)
+		if err != nil {
+			return false, fmt.Errorf("reading holiday calendar: %w", err)
+		}
+
+		modTime = info.ModTime()
+
+		hc.mu.Lock()
+		unchanged := hc.days != nil && hc.modTime.Equal(modTime)
+		hc.mu.Unlock()
+
+		if unchanged {
+			return false, nil
+		}
+
+		data, err := os.ReadFile(hc.fileName)
+		if err != nil {
+			return false, fmt.Errorf("reading holiday calendar: %w", err)
+		}
+
+		yearly, err = parseHolidayFile(string(data), hc.loc, days)
+		if err != nil {
+			return false, fmt.Errorf("parsing holiday calendar: %w", err)
+		}
+	}
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	hc.days = days
+	hc.yearly = yearly
+	hc.modTime = modTime
+
+	return true, nil
+}
+
+// run periodically reloads the calendar file until the context is cancelled.
+// onReload is called whenever the holidays change.
+func (hc *holidayCalendar) run(ctx *AppContext, onReload func()) {
+	if hc.fileName == "" {
+		return
+	}
+
+	for {
+		select {
+		case <-hc.clock.After(hc.reloadInterval):
+		case <-ctx.Done():
+			return
+		}
+
+		reloaded, err := hc.reload()
+		if err != nil {
+			ctx.Log.Error("failed to reload holiday calendar, keeping the previous one", "error", err)
+			continue
+		}
+
+		if reloaded {
+			ctx.Log.Info("holiday calendar has been reloaded", "file_name", hc.fileName)
+			onReload()
+		}
+	}
+}
+
+// contains checks whether t falls on a holiday.
+func (hc *holidayCalendar) contains(t time.Time) bool {
+	t = t.In(hc.loc)
+	y, m, d := t.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, hc.loc)
+
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if _, ok := hc.days[day.Format(time.DateOnly)]; ok {
+		return true
+	}
+
+	for _, h := range hc.yearly {
+		// A holiday that has started in the previous year may last until this one
+		for _, year := range []int{y - 1, y} {
+			if year < h.firstYear || (h.lastYear != 0 && year > h.lastYear) {
+				continue
+			}
+
+			start := time.Date(year, h.month, h.day, 0, 0, 0, 0, hc.loc)
+			end := start.AddDate(0, 0, h.days)
+
+			if !day.Before(start) && day.Before(end) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// nextWorkday returns the start of the first day after t that is not a holiday.
+func (hc *holidayCalendar) nextWorkday(t time.Time) time.Time {
+	y, m, d := t.In(hc.loc).Date()
+
+	for i := 1; i <= maxHolidayStreak; i++ {
+		day := time.Date(y, m, d+i, 0, 0, 0, 0, hc.loc)
+		if !hc.contains(day) {
+			return day
+		}
+	}
+
+	// The calendar is broken: there are no workdays at all
+	return time.Date(y, m, d+1, 0, 0, 0, 0, hc.loc)
+}
+
+// parseHolidayFile parses either an iCalendar file or a list of dates (one per line),
+// adding the holidays to days. Recurring holidays are returned separately.
+func parseHolidayFile(data string, loc *time.Location, days map[string]struct{}) ([]yearlyHoliday, error) {
+	data = strings.TrimPrefix(data, "\ufeff")
+
+	if strings.HasPrefix(strings.TrimSpace(data), "BEGIN:VCALENDAR") {
+		return parseICS(data, loc, days)
+	}
+
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		t, err := time.ParseInLocation(time.DateOnly, line, loc)
+		if err != nil {
+			return nil, fmt.Errorf("line %v: invalid date %q (must be YYYY-MM-DD)", i+1, line)
+		}
+
+		days[t.Format(time.DateOnly)] = struct{}{}
+	}
+
+	return nil, nil
+}
+
+// icsEvent is a VEVENT component of an iCalendar file.
+type icsEvent struct {
+	start time.Time
+	end   time.Time
+	rrule string
+}
+
+// parseICS extracts the holidays from the events of an iCalendar (RFC 5545) file.
+// Every day that an event spans is considered a holiday.
+// Of the recurrence rules, only the yearly ones are supported.
+func parseICS(data string, loc *time.Location, days map[string]struct{}) ([]yearlyHoliday, error) {
+	// Unfold the long lines: a line that starts with whitespace continues the previous one
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\n ", "")
+	data = strings.ReplaceAll(data, "\n\t", "")
+
+	var (
+		yearly []yearlyHoliday
+		ev     *icsEvent
+		events int
+	)
+
+	for line := range strings.SplitSeq(data, "\n") {
+		nameWithParams, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+
+		name, params, _ := strings.Cut(nameWithParams, ";")
+
+		var err error
+
+		switch strings.ToUpper(name) {
+		case "BEGIN":
+			if value == "VEVENT" {
+				ev = &icsEvent{}
+				events++
+			}
+		case "END":
+			if value != "VEVENT" || ev == nil {
+				continue
+			}
+
+			if ev.start.IsZero() {
+				return nil, fmt.Errorf("event #%v has no start date", events)
+			}
+
+			var h *yearlyHoliday
+
+			h, err = ev.addTo(loc, days)
+			if h != nil {
+				yearly = append(yearly, *h)
+			}
+
+			ev = nil
+		case "DTSTART":
+			if ev != nil {
+				ev.start, err = parseICSTime(params, value, loc)
+			}
+		case "DTEND":
+			if ev != nil {
+				ev.end, err = parseICSTime(params, value, loc)
+			}
+		case "RRULE":
+			if ev != nil {
+				ev.rrule = value
+			}
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("event #%v: %w", events, err)
+		}
+	}
+
+	return yearly, nil
+}
+
+// parseICSTime parses the value of a DTSTART or DTEND property and converts it to the specified location.
+func parseICSTime(params, value string, loc *time.Location) (time.Time, error) {
+	// All-day events
+	if len(value) == len("20060102") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date: %q", value)
+		}
+
+		return t, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date-time: %q", value)
+		}
+
+		return t.In(loc), nil
+	}
+
+	// Floating times are interpreted in the calendar location unless TZID is specified
+	valueLoc := loc
+
+	for param := range strings.SplitSeq(params, ";") {
+		if tzid, ok := strings.CutPrefix(param, "TZID="); ok {
+			var err error
+
+			valueLoc, err = time.LoadLocation(strings.Trim(tzid, `"`))
+			if err != nil {
+				return time.Time{}, fmt.Errorf("loading time zone: %w", err)
+			}
+		}
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, valueLoc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date-time: %q", value)
+	}
+
+	return t.In(loc), nil
+}
+
+// addTo adds the days of a one-off event to days.
+// A yearly event is returned as a recurring holiday instead.
+func (ev *icsEvent) addTo(loc *time.Location, days map[string]struct{}) (*yearlyHoliday, error) {
+	y, m, d := ev.start.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+
+	// DTEND is exclusive
+	count := 1
+	if !ev.end.IsZero() {
+		ey, em, ed := ev.end.Add(-time.Nanosecond).Date()
+		last := time.Date(ey, em, ed, 0, 0, 0, 0, loc)
+
+		for day := start.AddDate(0, 0, 1); !day.After(last); day = day.AddDate(0, 0, 1) {
+			count++
+		}
+	}
+
+	if ev.rrule == "" {
+		for i := range count {
+			days[start.AddDate(0, 0, i).Format(time.DateOnly)] = struct{}{}
+		}
+
+		return nil, nil //nolint:nilnil
+	}
+
+	h := &yearlyHoliday{
+		month:     m,
+		day:       d,
+		days:      count,
+		firstYear: y,
+	}
+
+	for part := range strings.SplitSeq(ev.rrule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			if value != "YEARLY" {
+				return nil, fmt.Errorf("unsupported recurrence frequency: %q", value)
+			}
+		case "INTERVAL":
+			if value != "1" {
+				return nil, fmt.Errorf("unsupported recurrence interval: %q", value)
+			}
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid recurrence count: %q", value)
+			}
+
+			h.lastYear = y + n - 1
+		case "UNTIL":
+			if len(value) < len("20060102") {
+				return nil, fmt.Errorf("invalid recurrence end: %q", value)
+			}
+
+			until, err := time.Parse("20060102", value[:len("20060102")])
+			if err != nil {
+				return nil, fmt.Errorf("invalid recurrence end: %q", value)
+			}
+
+			// The occurrence of the last year may fall after the end of recurrence
+			h.lastYear = until.Year()
+			if time.Date(h.lastYear, m, d, 0, 0, 0, 0, time.UTC).After(until) {
+				h.lastYear--
+			}
+		case "WKST":
+		default:
+			return nil, fmt.Errorf("unsupported recurrence rule: %q", ev.rrule)
+		}
+	}
+
+	return h, nil
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when it is advanced.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeClockWaiter
+}
+
+type fakeClockWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+
+	c.waiters = append(c.waiters, fakeClockWaiter{c.now.Add(d), ch})
+	return ch
+}
+
+// advance moves the clock forward, firing the timers that have expired.
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+
+		w.ch <- c.now
+	}
+
+	c.waiters = pending
+}
+
+// waitForTimers blocks until someone waits for the clock.
+func (c *fakeClock) waitForTimers(t *testing.T) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		n := len(c.waiters)
+		c.mu.Unlock()
+
+		if n > 0 {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	t.Fatal("nobody is waiting for the clock")
+}
+
+const testICS = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:New Year holidays\r\n" +
+	"DTSTART;VALUE=DATE:20260101\r\n" +
+	"DTEND;VALUE=DATE:20260109\r\n" +
+	"RRULE:FREQ=YEARLY\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Defender of the Fatherland\r\n" +
+	"  Day\r\n" +
+	"DTSTART;VALUE=DATE:20260223\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Day off\r\n" +
+	"DTSTART;TZID=Europe/Moscow:20260309T000000\r\n" +
+	"DTEND;TZID=Europe/Moscow:20260310T000000\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+// TestHolidayCalendar checks that the holidays are loaded from iCalendar files and date lists.
+func TestHolidayCalendar(t *testing.T) {
+	msk, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("loading time zone: %v", err)
+	}
+
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, msk)
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		holidays []time.Time
+		workdays []time.Time
+	}{
+		{
+			name: "icalendar",
+			file: testICS,
+			holidays: []time.Time{
+				day(2026, 1, 1), day(2026, 1, 8), day(2027, 1, 5), day(2026, 2, 23), day(2026, 3, 9),
+				time.Date(2026, 3, 8, 21, 30, 0, 0, time.UTC),
+			},
+			workdays: []time.Time{
+				day(2025, 12, 31), day(2026, 1, 9), day(2025, 1, 1), day(2027, 2, 23), day(2026, 3, 10),
+			},
+		},
+		{
+			name:     "date list",
+			file:     "# Holidays\n2026-01-01\n\n2026-05-01\r\n",
+			holidays: []time.Time{day(2026, 1, 1), day(2026, 5, 1), day(2026, 6, 12)},
+			workdays: []time.Time{day(2026, 1, 2), day(2027, 1, 1)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := Config{}
+			cfg.Instantiate()
+			cfg.BoostWindows.TimeZone = "Europe/Moscow"
+			cfg.Holidays.FileName = filepath.Join(t.TempDir(), "holidays")
+			cfg.Holidays.Dates = []string{"2026-06-12"}
+
+			if err := os.WriteFile(cfg.Holidays.FileName, []byte(test.file), 0o600); err != nil {
+				t.Fatalf("writing holiday calendar: %v", err)
+			}
+
+			hc, err := newHolidayCalendar(&cfg, systemClock{})
+			if err != nil {
+				t.Fatalf("loading holiday calendar: %v", err)
+			}
+
+			for _, d := range test.holidays {
+				if !hc.contains(d) {
+					t.Errorf("%v is not a holiday", d)
+				}
+			}
+
+			for _, d := range test.workdays {
+				if hc.contains(d) {
+					t.Errorf("%v is a holiday", d)
+				}
+			}
+		})
+	}
+
+	t.Run("unsupported recurrence", func(t *testing.T) {
+		days := map[string]struct{}{}
+		ics := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20260101\nRRULE:FREQ=WEEKLY\nEND:VEVENT\nEND:VCALENDAR\n"
+
+		if _, err := parseHolidayFile(ics, msk, days); err == nil {
+			t.Error("expected an error but got nil")
+		}
+	})
+}
+
+// TestHolidayCalendarReload checks that the calendar is reloaded when the file changes.
+func TestHolidayCalendarReload(t *testing.T) {
+	cfg := Config{}
+	cfg.Instantiate()
+	cfg.BoostWindows.TimeZone = "UTC"
+	cfg.Holidays.FileName = filepath.Join(t.TempDir(), "holidays.txt")
+
+	writeFile := func(contents string, modTime time.Time) {
+		t.Helper()
+
+		if err := os.WriteFile(cfg.Holidays.FileName, []byte(contents), 0o600); err != nil {
+			t.Fatalf("writing holiday calendar: %v", err)
+		}
+
+		if err := os.Chtimes(cfg.Holidays.FileName, modTime, modTime); err != nil {
+			t.Fatalf("updating modification time: %v", err)
+		}
+	}
+
+	modTime := time.Now().Add(-time.Hour)
+	writeFile("2026-01-01\n", modTime)
+
+	clk := newFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	hc, err := newHolidayCalendar(&cfg, clk)
+	if err != nil {
+		t.Fatalf("loading holiday calendar: %v", err)
+	}
+
+	ctx := newTestAppContext(t, "https://hh.ru")
+	ctx.Context = t.Context()
+
+	reloaded := make(chan struct{}, 1)
+	go hc.run(ctx, func() {
+		reloaded <- struct{}{}
+	})
+
+	newYear := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	christmas := time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC)
+
+	// An unchanged file is not reloaded
+	clk.waitForTimers(t)
+	clk.advance(cfg.Holidays.ReloadInterval)
+
+	// A broken file does not replace the loaded holidays
+	clk.waitForTimers(t)
+	writeFile("tomorrow\n", modTime.Add(time.Minute))
+	clk.advance(cfg.Holidays.ReloadInterval)
+
+	clk.waitForTimers(t)
+	writeFile("2026-01-07\n", modTime.Add(2*time.Minute))
+	clk.advance(cfg.Holidays.ReloadInterval)
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("holiday calendar has not been reloaded")
+	}
+
+	select {
+	case <-reloaded:
+		t.Fatal("holiday calendar has been reloaded more than once")
+	default:
+	}
+
+	if hc.contains(newYear) || !hc.contains(christmas) {
+		t.Errorf("invalid holidays after reload: %v", hc.days)
+	}
+}
+
+// TestHolidayBoostPlanning checks that the boosts are skipped or reduced on holidays.
+func TestHolidayBoostPlanning(t *testing.T) {
+	cfg := Config{}
+	cfg.Instantiate()
+	cfg.BoostWindows.TimeZone = "UTC"
+	cfg.BoostWindows.Windows = []BoostWindow{{Start: "08:00", End: "20:00"}}
+	cfg.Holidays.Dates = []string{"2026-01-01", "2026-01-02"}
+
+	windows, err := parseBoostWindows(&cfg)
+	if err != nil {
+		t.Fatalf("parsing boost windows: %v", err)
+	}
+
+	holidays, err := newHolidayCalendar(&cfg, systemClock{})
+	if err != nil {
+		t.Fatalf("loading holiday calendar: %v", err)
+	}
+
+	sched := newResumeScheduler(nil, windows, holidays, nil)
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 12, 31+day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name            string
+		lastBoost       time.Time
+		now             time.Time
+		holidayInterval time.Duration
+		expected        time.Time
+	}{
+		{"workday", at(0, 9), at(0, 10), 0, at(0, 13)},
+		{"holidays are skipped", at(0, 18), at(0, 19), 0, at(3, 8)},
+		{"overdue on holidays", at(0, 18), at(1, 12), 0, at(3, 8)},
+		{"reduced interval", at(0, 18), at(0, 19), 16 * time.Hour, at(1, 10)},
+		{"reduced interval within holidays", at(1, 10), at(1, 11), 16 * time.Hour, at(2, 8)},
+		{"reduced interval until workday", at(2, 10), at(2, 11), 16 * time.Hour, at(3, 8)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := resumePolicy{interval: 4 * time.Hour, windows: windows}
+
+			next := sched.nextBoostTime(&policy, test.lastBoost, test.now, test.holidayInterval)
+			if !next.Equal(test.expected) {
+				t.Errorf("invalid boost time: got %v, expected %v", next, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	jitterUniform = "uniform"
+	jitterNormal  = "normal"
+)
+
+// jitter produces random delays that are added to the timers,
+// so that the requests to HH do not follow an exact pattern.
+// The delays are never negative, thus a jittered timer never fires earlier than it would without jitter.
+//
+// A nil jitter produces no delays.
+type jitter struct {
+	distribution string
+	minDelay     time.Duration
+	maxDelay     time.Duration
+
+	// rng is not safe for concurrent use, hence the mutex
+	rng *rand.Rand
+	mu  sync.Mutex
+}
+
+// newJitter creates a jitter source from the config.
+// If jitter is disabled, it returns nil.
+func newJitter(cfg *Config) *jitter {
+	if cfg.Jitter.Distribution == "" {
+		return nil
+	}
+
+	seed := uint64(cfg.Jitter.Seed) //nolint:gosec
+	if seed == 0 {
+		seed = rand.Uint64() //nolint:gosec
+	}
+
+	return &jitter{
+		distribution: cfg.Jitter.Distribution,
+		minDelay:     cfg.Jitter.Min,
+		maxDelay:     cfg.Jitter.Max,
+		rng:          rand.New(rand.NewPCG(seed, seed)), //nolint:gosec
+	}
+}
+
+// delay returns a random delay between the configured bounds.
+//
+// The normal distribution is centered between the bounds,
+// with the bounds being three standard deviations away from the mean;
+// the rare values that fall outside of the bounds are clamped.
+func (j *jitter) delay() time.Duration {
+	if j == nil {
+		return 0
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	span := float64(j.maxDelay - j.minDelay)
+
+	var offset float64
+
+	switch j.distribution {
+	case jitterNormal:
+		offset = span/2 + j.rng.NormFloat64()*span/6
+		offset = min(max(offset, 0), span)
+	default:
+		offset = j.rng.Float64() * span
+	}
+
+	return j.minDelay + time.Duration(offset)
+}
+
+// apply extends the duration by a random delay.
+func (j *jitter) apply(d time.Duration) time.Duration {
+	return d + j.delay()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestJitter checks that the random delays are bounded and reproducible.
+func TestJitter(t *testing.T) {
+	for _, distribution := range []string{jitterUniform, jitterNormal} {
+		t.Run(distribution, func(t *testing.T) {
+			cfg := Config{}
+			cfg.Jitter.Distribution = distribution
+			cfg.Jitter.Min = time.Minute
+			cfg.Jitter.Max = 5 * time.Minute
+			cfg.Jitter.Seed = 42
+
+			j1 := newJitter(&cfg)
+			j2 := newJitter(&cfg)
+
+			var sum time.Duration
+
+			const n = 1000
+			for range n {
+				d := j1.delay()
+				if d < cfg.Jitter.Min || d > cfg.Jitter.Max {
+					t.Fatalf("delay is out of bounds: %v", d)
+				}
+
+				if d2 := j2.delay(); d != d2 {
+					t.Fatalf("delays with the same seed differ: %v and %v", d, d2)
+				}
+
+				sum += d
+			}
+
+			// Both distributions are centered between the bounds
+			if mean := sum / n; mean < 2*time.Minute+30*time.Second || mean > 3*time.Minute+30*time.Second {
+				t.Errorf("invalid mean delay: %v", mean)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		j := newJitter(&Config{})
+		if d := j.apply(time.Minute); d != time.Minute {
+			t.Errorf("invalid delay: got %v, expected %v", d, time.Minute)
+		}
+	})
+}
@@ -1,93 +1,245 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log/slog"
 
 	"github.com/imroc/req/v3"
 )
 
-// hhAuthenticate authenticates against the HH backend.
-func hhAuthenticate(ctx *AppContext, cl *req.Client, xsrf string) error {
-	slog.Debug("authenticating in HH")
+// hhLoginResponse is the response of HH login endpoints.
+type hhLoginResponse struct {
+	Recaptcha struct {
+		IsBot bool `json:"isBot"`
+	} `json:"recaptcha"`
+
+	HHCaptcha struct {
+		IsBot        bool   `json:"isBot"`
+		CaptchaState string `json:"captchaState"`
+	} `json:"hhcaptcha"`
+
+	// OTP is set if HH requires a one-time code (sent via SMS) to complete the login
+	OTP struct {
+		Required bool `json:"required"`
+	} `json:"otp"`
+
+	RedirectURL string `json:"redirectUrl"`
+	LoginError  struct {
+		Code        string `json:"code"`
+		Translation string `json:"trl"`
+	} `json:"loginError"`
+}
 
-	r := cl.R()
-	r.SetHeaders(map[string]string{
-		"Sec-Fetch-Dest":   "empty",
-		"Sec-Fetch-Mode":   "cors",
-		"Sec-Fetch-Site":   "same-origin",
-		"X-Requested-With": "XMLHTTPRequest",
-		"X-Xsrftoken":      xsrf,
-		"X-Hhtmsource":     "account_login",
-		"X-Hhtmfrom":       "main",
-		"Accept":           "application/json",
-		"Referer":          buildHHURL(ctx, "/applicant/resumes?role=applicant"),
-	})
+// hhOTPResponse is the response of the HH endpoint that sends one-time codes.
+type hhOTPResponse struct {
+	Result struct {
+		CodeLength int `json:"codeLength"`
+		NextCodeIn int `json:"nextCodeIn"`
+	} `json:"result"`
 
-	r.EnableForceMultipart()
+	Error struct {
+		Key string `json:"key"`
+	} `json:"error"`
+}
+
+// err converts a login response into an error, if the response indicates a failure.
+func (resp *hhLoginResponse) err() error {
+	if resp.Recaptcha.IsBot {
+		return errors.New("triggered ReCaptcha bot protection")
+	}
+
+	if resp.HHCaptcha.IsBot {
+		return fmt.Errorf("triggered HHCaptcha bot protection: state = %v", resp.HHCaptcha.CaptchaState)
+	}
+
+	if resp.LoginError.Code != "" {
+		return fmt.Errorf("authentication failure: %q (%q)", resp.LoginError.Code, resp.LoginError.Translation)
+	}
+
+	return nil
+}
 
+// hhAuthenticate authenticates against the HH backend
+// and notifies the user if the authentication fails.
+func hhAuthenticate(ctx *AppContext, sess *hhSession, xsrf string) error {
+	err := hhLogin(ctx, sess, xsrf)
+	if err != nil {
+		ctx.Notifier.publish(ctx, event{Kind: eventAuthFailed, Error: err.Error()})
+	}
+
+	return err
+}
+
+// hhLogin performs the login flow, handing captchas and one-time codes off to a human if needed.
+func hhLogin(ctx *AppContext, sess *hhSession, xsrf string) error {
+	ctx.Log.Debug("authenticating in HH")
+	ctx.Metrics.authAttempts.WithLabelValues(ctx.Cfg.Name).Inc()
+
+	var (
+		loginResp *hhLoginResponse
+		captcha   *hhCaptcha
+		err       error
+	)
+
+	for attempt := 1; ; attempt++ {
+		loginResp, err = hhPostLoginForm(ctx, sess, xsrf, "/account/login?backurl=%2Fapplicant%2Fresumes&role=applicant", setCaptchaFields(map[string]string{
+			"accountType": "APPLICANT",
+			"remember":    "true",
+			"username":    ctx.Cfg.Login,
+			"password":    ctx.Cfg.Password,
+			"failUrl":     "/account/login?backurl=%2Fapplicant%2Fresumes&role=applicant",
+		}, captcha))
+		if err != nil {
+			return err
+		}
+
+		isBot := loginResp.HHCaptcha.IsBot || loginResp.Recaptcha.IsBot
+		if isBot {
+			ctx.Metrics.captchas.WithLabelValues(ctx.Cfg.Name).Inc()
+		}
+
+		// HHCaptcha can be handed off to a human; ReCaptcha can't, since it requires a real browser
+		if !loginResp.HHCaptcha.IsBot || sess.captcha == nil || attempt > maxCaptchaAttempts {
+			if isBot {
+				ctx.Notifier.publish(ctx, event{Kind: eventCaptchaRequired})
+			}
+
+			break
+		}
+
+		captcha, err = hhSolveCaptcha(ctx, sess, xsrf)
+		if err != nil {
+			return err
+		}
+	}
+
+	if loginResp.OTP.Required && loginResp.err() == nil {
+		loginResp, err = hhAuthenticateWithOTP(ctx, sess, xsrf)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = loginResp.err()
+	if err != nil {
+		return err
+	}
+
+	ctx.Log.Debug("authenticated successfully")
+	return nil
+}
+
+// hhAuthenticateWithOTP completes a two-step login:
+// it asks HH to send a one-time code via SMS, obtains the code from the configured source
+// and submits it.
+func hhAuthenticateWithOTP(ctx *AppContext, sess *hhSession, xsrf string) (*hhLoginResponse, error) {
+	if sess.otp == nil {
+		return nil, errors.New("HH requires a one-time code, but no one-time code source is configured")
+	}
+
+	ctx.Log.Info("HH requires a one-time code, requesting an SMS")
+
+	r := sess.cl.R()
+	setLoginHeaders(ctx, r, xsrf)
+	r.EnableForceMultipart()
 	r.SetFormData(map[string]string{
-		"accountType": "APPLICANT",
-		"remember":    "true",
-		"username":    ctx.Cfg.Login,
-		"password":    ctx.Cfg.Password,
-		"failUrl":     "/account/login?backurl=%2Fapplicant%2Fresumes&role=applicant",
+		"login":       ctx.Cfg.Login,
+		"otpType":     "phone",
 		"captchaText": "",
 	})
 
-	setGSSHeaders(cl, r)
+	setGSSHeaders(sess.cl, r)
 
-	resp, err := r.Post(buildHHURL(ctx, "/account/login?backurl=%2Fapplicant%2Fresumes&role=applicant"))
+	resp, err := r.Post(buildHHURL(ctx, "/account/otp_generate"))
 	if err != nil {
-		return fmt.Errorf("sending HTTP request: %w", err)
+		return nil, fmt.Errorf("sending HTTP request: %w", err)
 	}
 
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			slog.Error("failed to close response body", "error", closeErr)
+			ctx.Log.Error("failed to close response body", "error", closeErr)
 		}
 	}()
 
 	if !resp.IsSuccessState() {
-		return fmt.Errorf("received an HTTP error: status code %v", resp.StatusCode)
+		return nil, fmt.Errorf("received an HTTP error while requesting a one-time code: status code %v", resp.StatusCode)
 	}
 
-	var hhResponse struct {
-		Recaptcha struct {
-			IsBot bool `json:"isBot"`
-		} `json:"recaptcha"`
-
-		HHCaptcha struct {
-			IsBot        bool   `json:"isBot"`
-			CaptchaState string `json:"captchaState"`
-		} `json:"hhcaptcha"`
+	var otpResp hhOTPResponse
+	err = json.NewDecoder(resp.Body).Decode(&otpResp)
+	if err != nil {
+		return nil, fmt.Errorf("decoding one-time code response: %w", err)
+	}
 
-		RedirectURL string `json:"redirectUrl"`
-		LoginError  struct {
-			Code        string `json:"code"`
-			Translation string `json:"trl"`
-		} `json:"loginError"`
+	if otpResp.Error.Key != "" {
+		return nil, fmt.Errorf("failed to request a one-time code: %q", otpResp.Error.Key)
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&hhResponse)
+	waitCtx, cancel := context.WithTimeout(ctx, ctx.Cfg.OTP.Timeout)
+	defer cancel()
+
+	code, err := sess.otp.answer(waitCtx, ctx.Cfg.Login, nil)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("obtaining one-time code: %w", err)
 	}
 
-	if hhResponse.Recaptcha.IsBot {
-		return errors.New("triggered ReCaptcha bot protection")
+	ctx.Log.Debug("submitting one-time code")
+
+	return hhPostLoginForm(ctx, sess, xsrf, "/account/login/by_code?backurl=%2Fapplicant%2Fresumes&role=applicant", map[string]string{
+		"accountType": "APPLICANT",
+		"remember":    "true",
+		"username":    ctx.Cfg.Login,
+		"code":        code,
+		"captchaText": "",
+	})
+}
+
+// hhPostLoginForm submits a login form and decodes the response.
+func hhPostLoginForm(ctx *AppContext, sess *hhSession, xsrf, pathWithQuery string, form map[string]string) (*hhLoginResponse, error) {
+	r := sess.cl.R()
+	setLoginHeaders(ctx, r, xsrf)
+	r.EnableForceMultipart()
+	r.SetFormData(form)
+
+	setGSSHeaders(sess.cl, r)
+
+	resp, err := r.Post(buildHHURL(ctx, pathWithQuery))
+	if err != nil {
+		return nil, fmt.Errorf("sending HTTP request: %w", err)
 	}
 
-	if hhResponse.HHCaptcha.IsBot {
-		return fmt.Errorf("triggered HHCaptcha bot protection: state = %v", hhResponse.HHCaptcha.CaptchaState)
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			ctx.Log.Error("failed to close response body", "error", closeErr)
+		}
+	}()
+
+	if !resp.IsSuccessState() {
+		return nil, fmt.Errorf("received an HTTP error: status code %v", resp.StatusCode)
 	}
 
-	if hhResponse.LoginError.Code != "" {
-		return fmt.Errorf("authentication failure: %q (%q)", hhResponse.LoginError.Code, hhResponse.LoginError.Translation)
+	var loginResp hhLoginResponse
+	err = json.NewDecoder(resp.Body).Decode(&loginResp)
+	if err != nil {
+		return nil, err
 	}
 
-	slog.Debug("authenticated successfully")
-	return nil
+	return &loginResp, nil
+}
+
+// setLoginHeaders sets the headers that the HH frontend sends to the login endpoints.
+func setLoginHeaders(ctx *AppContext, r *req.Request, xsrf string) {
+	r.SetHeaders(map[string]string{
+		"Sec-Fetch-Dest":   "empty",
+		"Sec-Fetch-Mode":   "cors",
+		"Sec-Fetch-Site":   "same-origin",
+		"X-Requested-With": "XMLHTTPRequest",
+		"X-Xsrftoken":      xsrf,
+		"X-Hhtmsource":     "account_login",
+		"X-Hhtmfrom":       "main",
+		"Accept":           "application/json",
+		"Referer":          buildHHURL(ctx, "/applicant/resumes?role=applicant"),
+	})
 }
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFakeOTPLoginServer starts an HH imitation that requires a one-time code to log in.
+// The returned counter is incremented every time a code is requested.
+func newFakeOTPLoginServer(t *testing.T, validCode string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	codeRequests := &atomic.Int32{}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /account/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("password") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"otp": {"required": true}}`))
+	})
+
+	mux.HandleFunc("POST /account/otp_generate", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("login") == "" {
+			_, _ = w.Write([]byte(`{"error": {"key": "MISSING_LOGIN"}}`))
+			return
+		}
+
+		codeRequests.Add(1)
+		_, _ = w.Write([]byte(`{"result": {"codeLength": 4, "nextCodeIn": 60}}`))
+	})
+
+	mux.HandleFunc("POST /account/login/by_code", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != validCode {
+			_, _ = w.Write([]byte(`{"loginError": {"code": "CODE_INVALID", "trl": "Invalid code"}}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"redirectUrl": "/applicant/resumes"}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv, codeRequests
+}
+
+// TestAuthenticateWithOTP checks the two-step login flow with various one-time code sources.
+func TestAuthenticateWithOTP(t *testing.T) {
+	t.Run("stdin source", func(t *testing.T) {
+		srv, codeRequests := newFakeOTPLoginServer(t, "1234")
+		ctx := newTestAppContext(t, srv.URL)
+
+		out := &strings.Builder{}
+		sess := newHHSession(createHTTPClient(ctx), newStdinPrompt(strings.NewReader("1234\n"), out), nil)
+
+		if err := hhAuthenticate(ctx, sess, "xsrf-token"); err != nil {
+			t.Fatalf("authenticating: %v", err)
+		}
+
+		if n := codeRequests.Load(); n != 1 {
+			t.Errorf("invalid number of code requests: got %v, expected 1", n)
+		}
+
+		if !strings.Contains(out.String(), ctx.Cfg.Login) {
+			t.Errorf("prompt does not mention the login: %q", out.String())
+		}
+	})
+
+	t.Run("http source", func(t *testing.T) {
+		srv, _ := newFakeOTPLoginServer(t, "5678")
+		ctx := newTestAppContext(t, srv.URL)
+
+		otpPrompts := newPendingPrompts()
+		api := newAdminServer(otpPrompts, newPendingPrompts(), "")
+		sess := newHHSession(createHTTPClient(ctx), otpPrompts, nil)
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- hhAuthenticate(ctx, sess, "xsrf-token")
+		}()
+
+		// Keep submitting the code until the login starts waiting for it
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/api/otp", strings.NewReader(`{"code": "5678"}`))
+			r.Header.Set("Content-Type", "application/json")
+			api.handler().ServeHTTP(rec, r)
+
+			if rec.Code == http.StatusNoContent {
+				break
+			}
+
+			if time.Now().After(deadline) {
+				t.Fatalf("one-time code has not been accepted: %v", rec.Body.String())
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if err := <-errCh; err != nil {
+			t.Fatalf("authenticating: %v", err)
+		}
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		srv, _ := newFakeOTPLoginServer(t, "1234")
+		ctx := newTestAppContext(t, srv.URL)
+		sess := newHHSession(createHTTPClient(ctx), newStdinPrompt(strings.NewReader("0000\n"), &strings.Builder{}), nil)
+
+		if err := hhAuthenticate(ctx, sess, "xsrf-token"); err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+	})
+
+	t.Run("no source", func(t *testing.T) {
+		srv, codeRequests := newFakeOTPLoginServer(t, "1234")
+		ctx := newTestAppContext(t, srv.URL)
+		sess := newHHSession(createHTTPClient(ctx), nil, nil)
+
+		if err := hhAuthenticate(ctx, sess, "xsrf-token"); err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+
+		if n := codeRequests.Load(); n != 0 {
+			t.Errorf("code should not have been requested, but got %v requests", n)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		srv, _ := newFakeOTPLoginServer(t, "1234")
+		ctx := newTestAppContext(t, srv.URL)
+		ctx.Cfg.OTP.Timeout = 50 * time.Millisecond
+
+		var cancel context.CancelFunc
+		ctx.Context, cancel = context.WithCancel(t.Context())
+		defer cancel()
+
+		sess := newHHSession(createHTTPClient(ctx), newPendingPrompts(), nil)
+
+		if err := hhAuthenticate(ctx, sess, "xsrf-token"); err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+	})
+}
+
+// TestAuthenticateWithCaptcha checks that a captcha is handed off to a human through the admin API
+// and attached to the captcha_required event, and that the login is retried with the answer.
+func TestAuthenticateWithCaptcha(t *testing.T) {
+	logins := &atomic.Int32{}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /account/login", func(w http.ResponseWriter, r *http.Request) {
+		logins.Add(1)
+
+		if r.FormValue("captchaKey") != "captcha-key" || r.FormValue("captchaText") != "abcd" {
+			_, _ = w.Write([]byte(`{"hhcaptcha": {"isBot": true, "captchaState": "INITIAL"}}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"redirectUrl": "/applicant/resumes"}`))
+	})
+
+	mux.HandleFunc("POST /captcha", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"key": "captcha-key"}`))
+	})
+
+	mux.HandleFunc("GET /captcha/picture", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "captcha-key" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte("\x89PNG\r\n\x1a\ncaptcha"))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Context = t.Context()
+
+	recorder := &eventRecorder{events: make(chan *event, 16)}
+	ctx.Notifier.add(recorder, []eventKind{eventCaptchaRequired})
+	ctx.Notifier.run(ctx)
+
+	captchaPrompts := newPendingPrompts()
+	api := newAdminServer(newPendingPrompts(), captchaPrompts, "")
+	sess := newHHSession(createHTTPClient(ctx), nil, captchaPrompts)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- hhAuthenticate(ctx, sess, "xsrf-token")
+	}()
+
+	// Wait until the captcha shows up on the captcha page
+	deadline := time.Now().Add(5 * time.Second)
+	for len(captchaPrompts.logins()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("captcha has not been handed off")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	rec := httptest.NewRecorder()
+	api.handler().ServeHTTP(rec, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/captcha/image?login=%2B78005553535", nil))
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("invalid captcha image response: status %v, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	rec = httptest.NewRecorder()
+	r := httptest.NewRequestWithContext(t.Context(), http.MethodPost, "/captcha", strings.NewReader("login=%2B78005553535&answer=abcd"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	api.handler().ServeHTTP(rec, r)
+
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("captcha answer has not been accepted: %v", rec.Body.String())
+	}
+
+	if err := <-errCh; err != nil {
+		t.Fatalf("authenticating: %v", err)
+	}
+
+	if n := logins.Load(); n != 2 {
+		t.Errorf("invalid number of login attempts: got %v, expected 2", n)
+	}
+
+	select {
+	case ev := <-recorder.events:
+		if string(ev.Image) != "\x89PNG\r\n\x1a\ncaptcha" {
+			t.Errorf("invalid captcha image in the event: got %q", ev.Image)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("captcha_required event has not been published")
+	}
+}
@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 )
 
 // version defines the application version.
@@ -62,15 +66,121 @@ func runApp(ctx *AppContext, configPath string) error {
 	}
 
 	setupLogger(ctx)
+	ctx.Log = slog.Default()
+	ctx.Metrics = newAppMetrics()
 
-	cl := createHTTPClient(ctx)
+	accounts, err := ctx.Cfg.AccountConfigs()
+	if err != nil {
+		return fmt.Errorf("resolving account configs: %w", err)
+	}
+
+	prompts := newSharedPrompts()
+	api := newAdminServer(prompts.otp, prompts.captcha, ctx.Cfg.AdminAPI.Token)
+
+	if ctx.Cfg.AdminAPI.Enabled {
+		err = serveHTTP(ctx, "admin", ctx.Cfg.AdminAPI.Address, api.handler())
+		if err != nil {
+			return fmt.Errorf("starting admin API: %w", err)
+		}
+	}
+
+	health := newAppHealth()
+
+	if ctx.Cfg.Monitoring.Enabled {
+		mux := http.NewServeMux()
+		ctx.Metrics.register(mux)
+		health.register(mux)
+
+		err = serveHTTP(ctx, "monitoring", ctx.Cfg.Monitoring.Address, mux)
+		if err != nil {
+			return fmt.Errorf("starting monitoring server: %w", err)
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	errs := make([]error, len(accounts))
+
+	for i, acc := range accounts {
+		actx := &AppContext{
+			Context: ctx,
+			Cfg:     acc,
+			Log:     ctx.Log,
+			Metrics: ctx.Metrics,
+			Health:  health.account(acc.Name),
+			Jitter:  newJitter(&acc),
+		}
+
+		// Log lines are only tagged if there is more than one account
+		if len(accounts) > 1 {
+			actx.Log = ctx.Log.With("account", acc.Name)
+		}
+
+		actx.Breaker = newCircuitBreaker(actx, systemClock{})
+		actx.Notifier = newNotificationHubFromConfig(actx)
+		actx.Notifier.run(actx)
+
+		wg.Go(func() {
+			err := runAccount(actx, prompts, api)
+			if err != nil {
+				errs[i] = fmt.Errorf("account %q: %w", acc.Name, err)
+			}
+		})
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// runAccount runs the discovery and boost loops for a single account.
+func runAccount(ctx *AppContext, prompts *sharedPrompts, api *adminServer) error {
+	ctx.Cfg.ChromeVersion = resolveChromeVersion(ctx, time.Now())
+	ctx.Chrome = newChromeVersionSource(ctx, systemClock{})
+	if ctx.Chrome != nil {
+		go ctx.Chrome.run(ctx)
+	}
+
+	sess := newHHSession(createHTTPClient(ctx), newOTPSource(ctx, prompts), newCaptchaSolver(ctx, prompts))
+
+	state, err := loadStateStore(ctx.Cfg.StateFileName)
+	if err != nil {
+		return fmt.Errorf("loading boost state: %w", err)
+	}
+
+	windows, err := parseBoostWindows(&ctx.Cfg)
+	if err != nil {
+		return fmt.Errorf("parsing boost windows: %w", err)
+	}
 
-	sched := newResumeScheduler()
+	holidays, err := newHolidayCalendar(&ctx.Cfg, systemClock{})
+	if err != nil {
+		return fmt.Errorf("loading holidays: %w", err)
+	}
+
+	overrides, err := parseResumeOverrides(&ctx.Cfg)
+	if err != nil {
+		return fmt.Errorf("parsing resume overrides: %w", err)
+	}
+
+	sched := newResumeScheduler(state, windows, holidays, overrides)
 	defer sched.teardown()
 
-	// Main logic loop: repeatedly discover resumes and schedule them
-	for resume := range discoverResumes(ctx, cl) {
-		sched.schedule(ctx, cl, resume)
+	if holidays != nil {
+		go holidays.run(ctx, sched.replan)
+	}
+
+	ctx.Health.setScheduler(sched)
+
+	discovery := newDiscoveryTrigger()
+	api.attach(&adminAccount{
+		ctx:       ctx,
+		sess:      sess,
+		sched:     sched,
+		discovery: discovery,
+	})
+
+	// Main logic loop: repeatedly discover resumes and reconcile the scheduler against them
+	for resumes := range discoverResumes(ctx, sess, discovery) {
+		sched.reconcile(ctx, sess, resumes)
 	}
 
 	// When there's nothing to discover anymore,
@@ -79,7 +189,7 @@ func runApp(ctx *AppContext, configPath string) error {
 	select {
 	case <-sched.done():
 	case <-ctx.Done():
-		slog.Debug("shutting down due to context cancellation")
+		ctx.Log.Debug("shutting down due to context cancellation")
 	}
 
 	return nil
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsNamespace prefixes the names of all exported metrics.
+const metricsNamespace = "hh_resume_auto_boost"
+
+// Boost results, as reported by the boost counter.
+const (
+	boostResultSucceeded = "succeeded"
+	boostResultTooEarly  = "too_early"
+	boostResultFailed    = "failed"
+)
+
+// appMetrics holds the Prometheus collectors of the application.
+// All metrics are labelled with the account name, so that a single instance
+// may serve several accounts.
+type appMetrics struct {
+	registry *prometheus.Registry
+
+	boostAttempts *prometheus.CounterVec
+	boosts        *prometheus.CounterVec
+	nextBoost     *prometheus.GaugeVec
+
+	discoveryRuns     *prometheus.CounterVec
+	discoveryFailures *prometheus.CounterVec
+
+	authAttempts *prometheus.CounterVec
+	captchas     *prometheus.CounterVec
+
+	httpRequestDuration *prometheus.HistogramVec
+
+	circuitBreakerState *prometheus.GaugeVec
+	circuitBreakerTrips *prometheus.CounterVec
+}
+
+func newAppMetrics() *appMetrics {
+	m := &appMetrics{
+		registry: prometheus.NewRegistry(),
+
+		boostAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "boost_attempts_total",
+			Help:      "Number of resume boost attempts.",
+		}, []string{"account", "resume"}),
+
+		boosts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "boosts_total",
+			Help:      "Number of finished resume boost attempts by result (succeeded, too_early or failed).",
+		}, []string{"account", "resume", "result"}),
+
+		nextBoost: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "next_boost_timestamp_seconds",
+			Help:      "Unix time of the next scheduled boost of a resume.",
+		}, []string{"account", "resume"}),
+
+		discoveryRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "discovery_runs_total",
+			Help:      "Number of resume discovery passes.",
+		}, []string{"account"}),
+
+		discoveryFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "discovery_failures_total",
+			Help:      "Number of failed resume discovery passes.",
+		}, []string{"account"}),
+
+		authAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "authentication_attempts_total",
+			Help:      "Number of attempts to log into HH.",
+		}, []string{"account"}),
+
+		captchas: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "captchas_total",
+			Help:      "Number of captchas that HH has shown during login.",
+		}, []string{"account"}),
+
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests to HH by path and status code.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 25, 50},
+		}, []string{"account", "method", "path", "code"}),
+
+		circuitBreakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "circuit_breaker_state",
+			Help:      "State of the circuit breaker: 0 if closed, 1 if half-open, 2 if open.",
+		}, []string{"account"}),
+
+		circuitBreakerTrips: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "circuit_breaker_trips_total",
+			Help:      "Number of times the circuit breaker has paused the requests to HH.",
+		}, []string{"account"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+
+		m.boostAttempts,
+		m.boosts,
+		m.nextBoost,
+		m.discoveryRuns,
+		m.discoveryFailures,
+		m.authAttempts,
+		m.captchas,
+		m.httpRequestDuration,
+		m.circuitBreakerState,
+		m.circuitBreakerTrips,
+	)
+
+	return m
+}
+
+// register adds the /metrics endpoint, which exposes the metrics in the Prometheus format, to the mux.
+func (m *appMetrics) register(mux *http.ServeMux) {
+	mux.Handle("GET /metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// forgetResume removes the per-resume series of a resume that is no longer scheduled.
+func (m *appMetrics) forgetResume(account, id string) {
+	m.nextBoost.DeleteLabelValues(account, id)
+}
+
+// instrumentHTTPClient makes the client report the latency of every request it sends.
+func (m *appMetrics) instrumentHTTPClient(account string, cl *req.Client) {
+	cl.WrapRoundTripFunc(func(rt req.RoundTripper) req.RoundTripFunc {
+		return func(r *req.Request) (*req.Response, error) {
+			start := time.Now()
+			resp, err := rt.RoundTrip(r)
+
+			code := "error"
+			if err == nil && resp.Response != nil {
+				code = strconv.Itoa(resp.StatusCode)
+			}
+
+			path := ""
+			if r.URL != nil {
+				path = r.URL.Path
+			}
+
+			m.httpRequestDuration.WithLabelValues(account, r.Method, path, code).Observe(time.Since(start).Seconds())
+			return resp, err
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMetrics checks that boosts, logins and HTTP requests are reflected on the /metrics page.
+func TestMetrics(t *testing.T) {
+	srv, _ := newFakeHHServer(t)
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Cfg.Name = "test"
+
+	sess := newHHSession(createHTTPClient(ctx), nil, nil)
+	state, err := loadStateStore("")
+	if err != nil {
+		t.Fatalf("loading state: %v", err)
+	}
+
+	sched := newResumeScheduler(state, nil, nil, nil)
+	entry := &scheduledResume{resume: &hhResume{id: "abc", title: "test"}}
+
+	if err := sched.exclusiveBoost(ctx, sess, entry, nil); err != nil {
+		t.Fatalf("boosting resume: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	ctx.Metrics.register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("invalid status code: got %v, expected %v", rec.Code, http.StatusOK)
+	}
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		`hh_resume_auto_boost_boost_attempts_total{account="test",resume="abc"} 1`,
+		`hh_resume_auto_boost_boosts_total{account="test",result="succeeded",resume="abc"} 1`,
+		`hh_resume_auto_boost_authentication_attempts_total{account="test"} 1`,
+		`hh_resume_auto_boost_http_request_duration_seconds_count{account="test",code="403",method="POST",path="/applicant/resumes/touch"} 1`,
+		`hh_resume_auto_boost_http_request_duration_seconds_count{account="test",code="200",method="POST",path="/applicant/resumes/touch"} 1`,
+	} {
+		if !strings.Contains(body, line) {
+			t.Errorf("missing metric: %v", line)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// notificationQueueSize limits the number of events that may wait for delivery to a notifier.
+// Events that do not fit are dropped, so that a slow notifier never stalls the boosts.
+const notificationQueueSize = 64
+
+type eventKind string
+
+const (
+	eventBoostScheduled   eventKind = "boost_scheduled"
+	eventBoostSucceeded   eventKind = "boost_succeeded"
+	eventBoostFailed      eventKind = "boost_failed"
+	eventBoostFailing     eventKind = "boost_failing"
+	eventBoostSuspended   eventKind = "boost_suspended"
+	eventAuthFailed       eventKind = "auth_failed"
+	eventCaptchaRequired  eventKind = "captcha_required"
+	eventResumeDiscovered eventKind = "resume_discovered"
+	eventResumeEvicted    eventKind = "resume_evicted"
+	eventDiscoveryStopped eventKind = "discovery_stopped"
+)
+
+// eventKinds lists all known event kinds.
+var eventKinds = []eventKind{
+	eventBoostScheduled,
+	eventBoostSucceeded,
+	eventBoostFailed,
+	eventBoostFailing,
+	eventBoostSuspended,
+	eventAuthFailed,
+	eventCaptchaRequired,
+	eventResumeDiscovered,
+	eventResumeEvicted,
+	eventDiscoveryStopped,
+}
+
+// event is something that the user should be notified about.
+type event struct {
+	Kind    eventKind `json:"kind"`
+	Time    time.Time `json:"time"`
+	Account string    `json:"account"`
+
+	ResumeID    string `json:"resume_id,omitempty"`
+	ResumeTitle string `json:"resume_title,omitempty"`
+
+	NextBoost time.Time `json:"next_boost,omitzero"`
+
+	// Failures is the number of consecutive failures that have led to the event
+	Failures int `json:"failures,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	// Image is the captcha image that has been handed off to a human; it is base64-encoded in JSON
+	Image []byte `json:"image,omitempty"`
+
+	// Text is a human-readable description of the event
+	Text string `json:"text"`
+}
+
+// text returns a human-readable description of the event.
+func (ev *event) text() string {
+	var text string
+
+	switch ev.Kind {
+	case eventBoostScheduled:
+		text = fmt.Sprintf("Resume %q will be boosted at %v", ev.ResumeTitle, ev.NextBoost.Format(time.DateTime))
+	case eventBoostSucceeded:
+		text = fmt.Sprintf("Resume %q has been boosted", ev.ResumeTitle)
+	case eventBoostFailed:
+		text = fmt.Sprintf("Resume %q has failed to boost: %v", ev.ResumeTitle, ev.Error)
+	case eventBoostFailing:
+		text = fmt.Sprintf("Resume %q has failed to boost %v times in a row: %v", ev.ResumeTitle, ev.Failures, ev.Error)
+	case eventBoostSuspended:
+		text = fmt.Sprintf("Boosts of resume %q have been suspended after %v failed attempts: %v", ev.ResumeTitle, ev.Failures, ev.Error)
+	case eventAuthFailed:
+		text = "Failed to log into HH: " + ev.Error
+	case eventCaptchaRequired:
+		text = "HH requires a captcha to log in"
+	case eventResumeDiscovered:
+		text = fmt.Sprintf("Resume %q has been discovered", ev.ResumeTitle)
+	case eventResumeEvicted:
+		text = fmt.Sprintf("Resume %q is no longer available or eligible", ev.ResumeTitle)
+	case eventDiscoveryStopped:
+		text = fmt.Sprintf("Resume discovery has been stopped after %v consecutive failures: %v", ev.Failures, ev.Error)
+	default:
+		text = string(ev.Kind)
+	}
+
+	return "[" + ev.Account + "] " + text
+}
+
+// parseEventKinds validates a list of event kinds from the config.
+func parseEventKinds(names []string) ([]eventKind, error) {
+	kinds := make([]eventKind, 0, len(names))
+
+	for _, name := range names {
+		kind := eventKind(name)
+		if !slices.Contains(eventKinds, kind) {
+			return nil, fmt.Errorf("unknown event kind: %q", name)
+		}
+
+		kinds = append(kinds, kind)
+	}
+
+	return kinds, nil
+}
+
+// notifier delivers events to the user.
+type notifier interface {
+	// name identifies the notifier in logs
+	name() string
+
+	notify(ctx context.Context, ev *event) error
+}
+
+// backgroundNotifier is a notifier that also needs to do some work in background,
+// e.g. to send the accumulated events periodically.
+type backgroundNotifier interface {
+	notifier
+
+	run(ctx *AppContext)
+}
+
+// notificationSink is a notifier along with its own event queue,
+// so that a slow notifier does not delay the others.
+type notificationSink struct {
+	notifier notifier
+	kinds    []eventKind
+	queue    chan *event
+}
+
+// notificationHub fans the events of an account out to the configured notifiers.
+// Delivery is asynchronous: events are queued and sent by background goroutines.
+type notificationHub struct {
+	sinks []*notificationSink
+}
+
+func newNotificationHub() *notificationHub {
+	return &notificationHub{}
+}
+
+// newNotificationHubFromConfig creates a hub with the notifiers that are enabled in the account config.
+// The config must have been validated beforehand.
+func newNotificationHubFromConfig(ctx *AppContext) *notificationHub {
+	hub := newNotificationHub()
+	cfg := &ctx.Cfg.Notifications
+
+	if cfg.Telegram.BotToken != "" {
+		kinds, _ := parseEventKinds(cfg.Telegram.Events)
+		hub.add(newTelegramNotifier(ctx), kinds)
+	}
+
+	if cfg.Webhook.URL != "" {
+		kinds, _ := parseEventKinds(cfg.Webhook.Events)
+		hub.add(newWebhookNotifier(ctx), kinds)
+	}
+
+	if cfg.Email.Host != "" {
+		hub.add(newEmailDigest(ctx), nil)
+	}
+
+	return hub
+}
+
+// add registers a notifier that receives the specified kinds of events.
+// If kinds is empty, the notifier receives all events.
+// It must not be called after the hub has been started.
+func (hub *notificationHub) add(n notifier, kinds []eventKind) {
+	hub.sinks = append(hub.sinks, &notificationSink{
+		notifier: n,
+		kinds:    kinds,
+		queue:    make(chan *event, notificationQueueSize),
+	})
+}
+
+// publish queues an event for delivery.
+// Account, Time and Text are filled in automatically.
+func (hub *notificationHub) publish(ctx *AppContext, ev event) {
+	if len(hub.sinks) == 0 {
+		return
+	}
+
+	ev.Account = ctx.Cfg.Name
+	ev.Time = time.Now()
+	ev.Text = ev.text()
+
+	for _, sink := range hub.sinks {
+		if len(sink.kinds) > 0 && !slices.Contains(sink.kinds, ev.Kind) {
+			continue
+		}
+
+		select {
+		case sink.queue <- &ev:
+		default:
+			ctx.Log.Warn("notification queue is full, dropping event", "notifier", sink.notifier.name(), "kind", ev.Kind)
+		}
+	}
+}
+
+// run delivers the queued events in background until the context is cancelled.
+func (hub *notificationHub) run(ctx *AppContext) {
+	for _, sink := range hub.sinks {
+		go sink.run(ctx)
+
+		if bn, ok := sink.notifier.(backgroundNotifier); ok {
+			go bn.run(ctx)
+		}
+	}
+}
+
+func (sink *notificationSink) run(ctx *AppContext) {
+	for {
+		select {
+		case ev := <-sink.queue:
+			err := sink.notifier.notify(ctx, ev)
+			if err != nil {
+				ctx.Log.Error("failed to send notification", "notifier", sink.notifier.name(), "kind", ev.Kind, "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
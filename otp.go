@@ -0,0 +1,22 @@
+package main
+
+const (
+	otpSourceStdin = "stdin"
+	otpSourceFile  = "file"
+	otpSourceHTTP  = "http"
+)
+
+// newOTPSource creates the one-time code source according to the config.
+// It returns nil if one-time codes are not configured.
+func newOTPSource(ctx *AppContext, prompts *sharedPrompts) answerSource {
+	switch ctx.Cfg.OTP.Source {
+	case otpSourceStdin:
+		return prompts.stdin
+	case otpSourceFile:
+		return newFilePrompt(ctx.Cfg.OTP.FileName, "", ctx.Cfg.OTP.PollInterval)
+	case otpSourceHTTP:
+		return prompts.otp
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resumePolicy determines how a specific resume is boosted.
+type resumePolicy struct {
+	interval time.Duration
+
+	// windows is nil if the boosts of the resume are not restricted
+	windows *boostWindows
+
+	// Resumes with higher priority are boosted first if several of them are due at once
+	priority int
+
+	// paused resumes are not boosted until the config changes
+	paused bool
+}
+
+// resumeOverride is a parsed ResumeOverride from the config.
+type resumeOverride struct {
+	ids        []string
+	substrings []string
+
+	interval time.Duration
+	windows  *boostWindows
+	priority int
+	paused   bool
+}
+
+// parseResumeOverrides parses the per-resume schedule overrides from the config.
+func parseResumeOverrides(cfg *Config) ([]resumeOverride, error) {
+	overrides := make([]resumeOverride, 0, len(cfg.ResumeOverrides))
+
+	for i, ro := range cfg.ResumeOverrides {
+		if len(ro.IDs) == 0 && len(ro.Substrings) == 0 {
+			return nil, fmt.Errorf("resume override #%v: no resume IDs or title substrings to match", i+1)
+		}
+
+		if ro.BoostInterval != 0 && ro.BoostInterval < 10*time.Minute {
+			return nil, fmt.Errorf("resume override #%v: boost interval is too low", i+1)
+		}
+
+		override := resumeOverride{
+			ids:        ro.IDs,
+			substrings: ro.Substrings,
+			interval:   ro.BoostInterval,
+			priority:   ro.Priority,
+			paused:     ro.Paused,
+		}
+
+		if len(ro.Windows) > 0 {
+			// Overrides share the time zone with the account-wide windows
+			windowsCfg := Config{}
+			windowsCfg.BoostWindows.TimeZone = cfg.BoostWindows.TimeZone
+			windowsCfg.BoostWindows.Windows = ro.Windows
+
+			var err error
+
+			override.windows, err = parseBoostWindows(&windowsCfg)
+			if err != nil {
+				return nil, fmt.Errorf("resume override #%v: %w", i+1, err)
+			}
+		}
+
+		overrides = append(overrides, override)
+	}
+
+	return overrides, nil
+}
+
+// matches checks whether the override applies to the resume.
+// IDs and substrings are expected to be lowercased by Config.normalize.
+func (ro *resumeOverride) matches(resume *hhResume) bool {
+	if slices.Contains(ro.ids, strings.ToLower(resume.id)) {
+		return true
+	}
+
+	lctitle := strings.ToLower(resume.title)
+	for _, substr := range ro.substrings {
+		if strings.Contains(lctitle, substr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveResumePolicy determines the policy of an eligible resume:
+// the first override that matches the resume is applied on top of the account-wide settings.
+func resolveResumePolicy(ctx *AppContext, overrides []resumeOverride, windows *boostWindows, resume *hhResume) resumePolicy {
+	policy := resumePolicy{
+		interval: ctx.Cfg.BoostInterval,
+		windows:  windows,
+	}
+
+	for i := range overrides {
+		ro := &overrides[i]
+		if !ro.matches(resume) {
+			continue
+		}
+
+		if ro.interval != 0 {
+			policy.interval = ro.interval
+		}
+
+		if ro.windows != nil {
+			policy.windows = ro.windows
+		}
+
+		policy.priority = ro.priority
+		policy.paused = ro.paused
+
+		break
+	}
+
+	return policy
+}
+
+// priorityLock is a mutex that is handed over to the waiter with the highest priority
+// (in the order of arrival among the waiters with the same priority).
+type priorityLock struct {
+	mu      sync.Mutex
+	locked  bool
+	waiters []*priorityWaiter
+}
+
+type priorityWaiter struct {
+	priority int
+	ready    chan struct{}
+}
+
+func (pl *priorityLock) lock(priority int) {
+	pl.mu.Lock()
+
+	if !pl.locked {
+		pl.locked = true
+		pl.mu.Unlock()
+
+		return
+	}
+
+	w := &priorityWaiter{
+		priority: priority,
+		ready:    make(chan struct{}),
+	}
+	pl.waiters = append(pl.waiters, w)
+	pl.mu.Unlock()
+
+	// The lock is handed over by unlock without being released
+	<-w.ready
+}
+
+func (pl *priorityLock) unlock() {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	if len(pl.waiters) == 0 {
+		pl.locked = false
+		return
+	}
+
+	next := 0
+	for i, w := range pl.waiters {
+		if w.priority > pl.waiters[next].priority {
+			next = i
+		}
+	}
+
+	w := pl.waiters[next]
+	pl.waiters = slices.Delete(pl.waiters, next, next+1)
+	close(w.ready)
+}
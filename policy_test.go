@@ -0,0 +1,106 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestResolveResumePolicy checks that the resume overrides are matched and applied.
+func TestResolveResumePolicy(t *testing.T) {
+	ctx := newTestAppContext(t, "https://hh.ru")
+	ctx.Cfg.BoostWindows.TimeZone = "UTC"
+	ctx.Cfg.ResumeOverrides = []ResumeOverride{
+		{IDs: []string{"ABC"}, BoostInterval: 6 * time.Hour, Priority: 10},
+		{Substrings: []string{"Go"}, Windows: []BoostWindow{{Start: "09:00", End: "18:00"}}},
+		{Substrings: []string{"developer"}, Paused: true},
+	}
+	ctx.Cfg.normalize()
+
+	overrides, err := parseResumeOverrides(&ctx.Cfg)
+	if err != nil {
+		t.Fatalf("parsing resume overrides: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		resume   hhResume
+		interval time.Duration
+		windows  bool
+		priority int
+		paused   bool
+	}{
+		{"matched by id", hhResume{id: "abc", title: "Go developer"}, 6 * time.Hour, false, 10, false},
+		{"first substring match wins", hhResume{id: "def", title: "Senior GO developer"}, ctx.Cfg.BoostInterval, true, 0, false},
+		{"paused", hhResume{id: "def", title: "Python developer"}, ctx.Cfg.BoostInterval, false, 0, true},
+		{"no match", hhResume{id: "def", title: "Manager"}, ctx.Cfg.BoostInterval, false, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := resolveResumePolicy(ctx, overrides, nil, &test.resume)
+
+			if policy.interval != test.interval {
+				t.Errorf("invalid boost interval: got %v, expected %v", policy.interval, test.interval)
+			}
+
+			if (policy.windows != nil) != test.windows {
+				t.Errorf("invalid boost windows: got %v, expected windows: %v", policy.windows, test.windows)
+			}
+
+			if policy.priority != test.priority {
+				t.Errorf("invalid priority: got %v, expected %v", policy.priority, test.priority)
+			}
+
+			if policy.paused != test.paused {
+				t.Errorf("invalid paused flag: got %v, expected %v", policy.paused, test.paused)
+			}
+		})
+	}
+}
+
+// TestPriorityLock checks that the waiters with higher priority acquire the lock first.
+func TestPriorityLock(t *testing.T) {
+	pl := &priorityLock{}
+	pl.lock(0)
+
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+
+	for i, priority := range []int{1, 5, 1, 3} {
+		wg.Go(func() {
+			pl.lock(priority)
+			defer pl.unlock()
+
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		})
+
+		// Make sure that the waiters are queued in order
+		for {
+			pl.mu.Lock()
+			queued := len(pl.waiters)
+			pl.mu.Unlock()
+
+			if queued == i+1 {
+				break
+			}
+
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	pl.unlock()
+	wg.Wait()
+
+	expected := []int{1, 3, 0, 2}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("invalid lock order: got %v, expected %v", order, expected)
+		}
+	}
+}
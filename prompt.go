@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sharedPrompts holds the prompts that are shared by all accounts.
+type sharedPrompts struct {
+	stdin   *stdinPrompt
+	otp     *pendingPrompts
+	captcha *pendingPrompts
+}
+
+func newSharedPrompts() *sharedPrompts {
+	return &sharedPrompts{
+		stdin:   newStdinPrompt(os.Stdin, os.Stdout),
+		otp:     newPendingPrompts(),
+		captcha: newPendingPrompts(),
+	}
+}
+
+// answerSource obtains an answer from a human:
+// either a one-time code or a captcha solution.
+type answerSource interface {
+	// answer blocks until an answer for the specified login becomes available.
+	// image is an optional picture (i.e. a captcha) that has to be shown to the human.
+	answer(ctx context.Context, login string, image []byte) (string, error)
+}
+
+// stdinPrompt interactively prompts the user for a one-time code.
+// Images are not supported, so it cannot be used for captchas.
+type stdinPrompt struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// The lines are read from in by a single goroutine, which is started by the first prompt,
+	// and handed to the prompt that is waiting for them. Reading from stdin cannot be cancelled,
+	// so the lines that arrive while no prompt is waiting are discarded: they answer the prompts
+	// that have already expired, and the code in them is stale
+	startOnce sync.Once
+
+	// waiter receives the next line; it is nil if no prompt is waiting.
+	// readErr is set once stdin has been closed or has failed. Both are guarded by waitMu
+	waiter  chan stdinLine
+	readErr error
+	waitMu  sync.Mutex
+
+	// mu prevents concurrent prompts from interleaving
+	mu sync.Mutex
+}
+
+// stdinLine is a line that has been read from stdin.
+type stdinLine struct {
+	line string
+	err  error
+}
+
+func newStdinPrompt(in io.Reader, out io.Writer) *stdinPrompt {
+	return &stdinPrompt{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// read reads the lines until stdin is closed or fails.
+func (p *stdinPrompt) read() {
+	for {
+		line, err := p.in.ReadString('\n')
+
+		p.waitMu.Lock()
+		if err != nil {
+			p.readErr = err
+		}
+
+		// The channel is buffered and receives a single line, so this never blocks
+		if p.waiter != nil {
+			p.waiter <- stdinLine{line, err}
+			p.waiter = nil
+		}
+		p.waitMu.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// wait registers the prompt as the receiver of the next line.
+func (p *stdinPrompt) wait() (chan stdinLine, error) {
+	p.waitMu.Lock()
+	defer p.waitMu.Unlock()
+
+	if p.readErr != nil {
+		return nil, p.readErr
+	}
+
+	p.waiter = make(chan stdinLine, 1)
+	return p.waiter, nil
+}
+
+// stopWaiting makes the lines that have not been received yet go to waste.
+func (p *stdinPrompt) stopWaiting(ch chan stdinLine) {
+	p.waitMu.Lock()
+	defer p.waitMu.Unlock()
+
+	if p.waiter == ch {
+		p.waiter = nil
+	}
+}
+
+func (p *stdinPrompt) answer(ctx context.Context, login string, _ []byte) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lines, err := p.wait()
+	if err != nil {
+		return "", fmt.Errorf("reading answer: %w", err)
+	}
+	defer p.stopWaiting(lines)
+
+	_, err = fmt.Fprintf(p.out, "Enter the one-time code that HH has sent to %v: ", login)
+	if err != nil {
+		return "", fmt.Errorf("writing prompt: %w", err)
+	}
+
+	p.startOnce.Do(func() {
+		go p.read()
+	})
+
+	select {
+	case res := <-lines:
+		answer := strings.TrimSpace(res.line)
+		if answer == "" {
+			if res.err != nil {
+				return "", fmt.Errorf("reading answer: %w", res.err)
+			}
+
+			return "", errors.New("empty answer")
+		}
+
+		return answer, nil
+
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// filePrompt polls a file until somebody writes an answer into it.
+// The file is removed after the answer has been read, so that the answer is not reused.
+type filePrompt struct {
+	answerFileName string
+	pollInterval   time.Duration
+
+	// imageFileName is the file where the image is saved, if there is one
+	imageFileName string
+}
+
+func newFilePrompt(answerFileName, imageFileName string, pollInterval time.Duration) *filePrompt {
+	p := &filePrompt{
+		answerFileName: filepath.Clean(answerFileName),
+		pollInterval:   pollInterval,
+	}
+
+	if imageFileName != "" {
+		p.imageFileName = filepath.Clean(imageFileName)
+	}
+
+	return p
+}
+
+func (p *filePrompt) answer(ctx context.Context, login string, image []byte) (string, error) {
+	requestedAt := time.Now()
+
+	if image != nil && p.imageFileName != "" {
+		err := os.WriteFile(p.imageFileName, image, 0o600)
+		if err != nil {
+			return "", fmt.Errorf("saving image: %w", err)
+		}
+
+		defer func() {
+			if err := os.Remove(p.imageFileName); err != nil {
+				slog.Warn("failed to remove image file", "error", err)
+			}
+		}()
+
+		slog.Warn("waiting for the answer to be written to a file", "login", login, "filename", p.answerFileName, "image", p.imageFileName)
+	} else {
+		slog.Warn("waiting for the answer to be written to a file", "login", login, "filename", p.answerFileName)
+	}
+
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		answer, err := p.tryRead(requestedAt)
+		if err != nil {
+			return "", err
+		}
+
+		if answer != "" {
+			return answer, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// tryRead returns the answer from the file if the file has been written after notBefore.
+// It returns an empty string if there's no answer yet.
+func (p *filePrompt) tryRead(notBefore time.Time) (string, error) {
+	fi, err := os.Stat(p.answerFileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("checking answer file: %w", err)
+	}
+
+	// Skip answers that have been left over from previous prompts
+	if fi.ModTime().Before(notBefore) {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(p.answerFileName)
+	if err != nil {
+		return "", fmt.Errorf("reading answer file: %w", err)
+	}
+
+	answer := strings.TrimSpace(string(data))
+	if answer == "" {
+		return "", nil
+	}
+
+	err = os.Remove(p.answerFileName)
+	if err != nil {
+		slog.Warn("failed to remove answer file", "error", err)
+	}
+
+	return answer, nil
+}
+
+// pendingPrompts waits for answers to be submitted through the admin API.
+type pendingPrompts struct {
+	// pending holds the logins that are currently waiting for an answer
+	pending map[string]*pendingPrompt
+	mu      sync.Mutex
+}
+
+type pendingPrompt struct {
+	image    []byte
+	answerCh chan string
+}
+
+func newPendingPrompts() *pendingPrompts {
+	return &pendingPrompts{
+		pending: map[string]*pendingPrompt{},
+	}
+}
+
+func (p *pendingPrompts) answer(ctx context.Context, login string, image []byte) (string, error) {
+	prompt := &pendingPrompt{
+		image:    image,
+		answerCh: make(chan string, 1),
+	}
+
+	p.mu.Lock()
+	p.pending[login] = prompt
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, login)
+		p.mu.Unlock()
+	}()
+
+	slog.Warn("waiting for the answer to be submitted through the admin API", "login", login)
+
+	select {
+	case answer := <-prompt.answerCh:
+		return answer, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// submit passes an answer to a pending login.
+// If login is empty, the answer is passed to the only pending login, if there's exactly one.
+func (p *pendingPrompts) submit(login, answer string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if login == "" && len(p.pending) == 1 {
+		for l := range p.pending {
+			login = l
+		}
+	}
+
+	prompt, ok := p.pending[login]
+	if !ok {
+		return errors.New("no login is waiting for an answer")
+	}
+
+	select {
+	case prompt.answerCh <- answer:
+		return nil
+	default:
+		return errors.New("an answer has already been submitted")
+	}
+}
+
+// logins returns a sorted list of logins that are waiting for an answer.
+func (p *pendingPrompts) logins() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Sorted(maps.Keys(p.pending))
+}
+
+// image returns the image attached to the pending login.
+func (p *pendingPrompts) image(login string) ([]byte, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prompt, ok := p.pending[login]
+	if !ok || prompt.image == nil {
+		return nil, false
+	}
+
+	return prompt.image, true
+}
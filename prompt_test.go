@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestFilePrompt checks that the file prompt picks up a freshly written answer
+// and ignores the stale ones.
+func TestFilePrompt(t *testing.T) {
+	t.Run("fresh answer", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "otp.txt")
+		imagePath := filepath.Join(dir, "captcha.png")
+		src := newFilePrompt(path, imagePath, 10*time.Millisecond)
+
+		imageCh := make(chan []byte, 1)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+
+			image, _ := os.ReadFile(imagePath)
+			imageCh <- image
+
+			_ = os.WriteFile(path, []byte(" 4321\n"), 0o600)
+		}()
+
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		defer cancel()
+
+		answer, err := src.answer(ctx, "+78005553535", []byte("image"))
+		if err != nil {
+			t.Fatalf("obtaining answer: %v", err)
+		}
+
+		if answer != "4321" {
+			t.Errorf("invalid answer: got %q, expected %q", answer, "4321")
+		}
+
+		if image := <-imageCh; string(image) != "image" {
+			t.Errorf("invalid image: got %q, expected %q", image, "image")
+		}
+
+		for _, p := range []string{path, imagePath} {
+			if _, err := os.Stat(p); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("%v should have been removed, got %v", p, err)
+			}
+		}
+	})
+
+	t.Run("stale answer", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "otp.txt")
+		if err := os.WriteFile(path, []byte("1111"), 0o600); err != nil {
+			t.Fatalf("writing answer file: %v", err)
+		}
+
+		past := time.Now().Add(-time.Hour)
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatalf("changing file times: %v", err)
+		}
+
+		src := newFilePrompt(path, "", 10*time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+		defer cancel()
+
+		if _, err := src.answer(ctx, "+78005553535", nil); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline error, got %v", err)
+		}
+	})
+}
+
+// TestStdinPromptCancellation checks that a line typed after a prompt has expired
+// is not taken as the answer to the next prompt.
+func TestStdinPromptCancellation(t *testing.T) {
+	r, w := io.Pipe()
+	t.Cleanup(func() { _ = w.Close() })
+
+	p := newStdinPrompt(r, io.Discard)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := p.answer(ctx, "+78005553535", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline error, got %v", err)
+	}
+
+	// The answer to the expired prompt; the write returns once the line has been read
+	if _, err := w.Write([]byte("1234\n")); err != nil {
+		t.Fatalf("writing answer: %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	answerCh := make(chan string, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		defer cancel()
+
+		answer, err := p.answer(ctx, "+78005553535", nil)
+		if err != nil {
+			t.Errorf("obtaining answer: %v", err)
+		}
+
+		answerCh <- answer
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.waitMu.Lock()
+		waiting := p.waiter != nil
+		p.waitMu.Unlock()
+
+		if waiting || time.Now().After(deadline) {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := w.Write([]byte("4321\n")); err != nil {
+		t.Fatalf("writing answer: %v", err)
+	}
+
+	if answer := <-answerCh; answer != "4321" {
+		t.Errorf("invalid answer: got %q, expected %q", answer, "4321")
+	}
+}
+
+// TestPendingPromptsSubmit checks that answers are only accepted for pending logins.
+func TestPendingPromptsSubmit(t *testing.T) {
+	src := newPendingPrompts()
+
+	if err := src.submit("", "1234"); err == nil {
+		t.Fatal("expected an error for an answer without a pending login")
+	}
+
+	answerCh := make(chan string, 1)
+	go func() {
+		answer, _ := src.answer(t.Context(), "user", nil)
+		answerCh <- answer
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for src.submit("user", "9876") != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("answer has not been accepted")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if answer := <-answerCh; answer != "9876" {
+		t.Errorf("invalid answer: got %q, expected %q", answer, "9876")
+	}
+}
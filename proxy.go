@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/imroc/req/v3"
+)
+
+const (
+	proxyModeFailover = "failover"
+	proxyModeRotate   = "rotate"
+)
+
+// proxyContextKey stores the proxy that has been picked for a request in the request context.
+type proxyContextKey struct{}
+
+// proxyPool picks the proxy for every request to HH.
+//
+// In the failover mode, all requests go through the same proxy until it cannot be connected to;
+// in the rotate mode, every request goes through the next proxy.
+// In both modes, a request that could not connect to its proxy is retried through the next one.
+type proxyPool struct {
+	proxies []*url.URL
+	mode    string
+
+	// redactor hides the proxy credentials
+	redactor *strings.Replacer
+
+	// current is the index of the proxy in use (failover) or of the next proxy (rotate)
+	current int
+	mu      sync.Mutex
+}
+
+// parseProxies parses the proxy URLs from the config.
+// The errors never include the URLs, since they may contain credentials.
+func parseProxies(cfg *Config) ([]*url.URL, error) {
+	var rawURLs []string
+	if cfg.Proxy.URL != "" {
+		rawURLs = append(rawURLs, cfg.Proxy.URL)
+	}
+
+	rawURLs = append(rawURLs, cfg.Proxy.URLs...)
+
+	proxies := make([]*url.URL, 0, len(rawURLs))
+	for i, rawURL := range rawURLs {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+
+			return nil, fmt.Errorf("proxy #%v: invalid URL: %w", i+1, err)
+		}
+
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("proxy #%v: unsupported scheme: %q (must be either \"http\", \"https\", \"socks5\" or \"socks5h\")", i+1, u.Scheme)
+		}
+
+		if u.Host == "" {
+			return nil, fmt.Errorf("proxy #%v: missing host", i+1)
+		}
+
+		proxies = append(proxies, u)
+	}
+
+	return proxies, nil
+}
+
+// newProxyPool creates a proxy pool from the config, which must have been validated beforehand.
+// If no proxies are configured, it returns nil.
+func newProxyPool(cfg *Config) *proxyPool {
+	proxies, _ := parseProxies(cfg)
+	if len(proxies) == 0 {
+		return nil
+	}
+
+	return &proxyPool{
+		proxies:  proxies,
+		mode:     cfg.Proxy.Mode,
+		redactor: newProxyRedactor(proxies),
+	}
+}
+
+// newProxyRedactor creates a replacer that hides the credentials of the proxies:
+// both in the URLs and in the Proxy-Authorization headers.
+func newProxyRedactor(proxies []*url.URL) *strings.Replacer {
+	var pairs []string
+
+	for _, u := range proxies {
+		if u.User == nil {
+			continue
+		}
+
+		password, ok := u.User.Password()
+		if !ok {
+			continue
+		}
+
+		credentials := u.User.Username() + ":" + password
+		pairs = append(pairs,
+			u.String(), u.Redacted(),
+			u.User.String()+"@", u.User.Username()+":xxxxx@",
+			base64.StdEncoding.EncodeToString([]byte(credentials)), "xxxxx",
+		)
+	}
+
+	return strings.NewReplacer(pairs...)
+}
+
+// instrument routes the requests of the client through the proxies.
+// Requests that could not connect to a proxy are retried through the other proxies.
+func (pp *proxyPool) instrument(cl *req.Client) {
+	if pp == nil {
+		return
+	}
+
+	cl.SetProxy(func(r *http.Request) (*url.URL, error) {
+		u, _ := r.Context().Value(proxyContextKey{}).(*url.URL)
+		return u, nil
+	})
+
+	cl.WrapRoundTripFunc(func(rt req.RoundTripper) req.RoundTripFunc {
+		return func(r *req.Request) (*req.Response, error) {
+			u := pp.pick()
+			r.SetContext(context.WithValue(r.Context(), proxyContextKey{}, u))
+
+			resp, err := rt.RoundTrip(r)
+			if err == nil {
+				return resp, nil
+			}
+
+			if isProxyConnectionError(err) {
+				pp.fail(u)
+			}
+
+			return resp, pp.redactError(err)
+		}
+	})
+
+	cl.SetCommonRetryCount(len(pp.proxies) - 1)
+	cl.SetCommonRetryCondition(func(_ *req.Response, err error) bool {
+		return isProxyConnectionError(err)
+	})
+}
+
+// pick returns the proxy for the next request.
+func (pp *proxyPool) pick() *url.URL {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	u := pp.proxies[pp.current]
+	if pp.mode == proxyModeRotate {
+		pp.current = (pp.current + 1) % len(pp.proxies)
+	}
+
+	return u
+}
+
+// fail switches away from a proxy that could not be connected to.
+// Rotation moves on to the next proxy anyway, so this only matters for the failover mode.
+func (pp *proxyPool) fail(u *url.URL) {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	if pp.mode != proxyModeFailover || pp.proxies[pp.current] != u {
+		return
+	}
+
+	pp.current = (pp.current + 1) % len(pp.proxies)
+}
+
+// redactError hides the proxy credentials from the error message, if there are any.
+func (pp *proxyPool) redactError(err error) error {
+	msg := err.Error()
+	if redacted := pp.redactor.Replace(msg); redacted != msg {
+		return &redactedError{msg: redacted, err: err}
+	}
+
+	return err
+}
+
+// redactDebugOutput hides the proxy credentials from the debug logs and HTTP dumps of the client.
+func (pp *proxyPool) redactDebugOutput(cl *req.Client) {
+	if pp == nil {
+		return
+	}
+
+	cl.SetLogger(&redactingLogger{
+		log:      req.NewLogger(os.Stdout, "", log.Ldate|log.Lmicroseconds),
+		redactor: pp.redactor,
+	})
+	cl.EnableDumpAllTo(&redactingWriter{w: os.Stdout, redactor: pp.redactor})
+}
+
+// socksTargetReplies are the SOCKS replies that report a failure to reach HH rather than the proxy itself.
+var socksTargetReplies = []string{
+	"network unreachable",
+	"host unreachable",
+	"connection refused",
+	"TTL expired",
+}
+
+// isProxyConnectionError checks whether the request has failed to connect to the proxy,
+// in which case it has not reached HH and can be safely retried through another proxy.
+//
+// Failures to reach HH through a working proxy are not proxy errors: the other proxies would not fare any better.
+func isProxyConnectionError(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+
+	switch {
+	case opErr.Op == "proxyconnect":
+		// The connection to the proxy itself, including the TLS handshake with an HTTPS proxy
+		return true
+	case strings.HasPrefix(opErr.Op, "socks"):
+		// The SOCKS handshake; the proxy has failed to reach HH if it replies with one of these
+		for _, reply := range socksTargetReplies {
+			if opErr.Err != nil && strings.HasSuffix(opErr.Err.Error(), reply) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return false
+	}
+}
+
+// redactedError is an error with sensitive data removed from its message.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactingWriter hides the proxy credentials from everything that is written to it.
+type redactingWriter struct {
+	w        io.Writer
+	redactor *strings.Replacer
+}
+
+func (rw *redactingWriter) Write(p []byte) (int, error) {
+	_, err := rw.redactor.WriteString(rw.w, string(p))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// redactingLogger hides the proxy credentials from the debug logs of req.
+type redactingLogger struct {
+	log      req.Logger
+	redactor *strings.Replacer
+}
+
+func (l *redactingLogger) Errorf(format string, v ...any) {
+	l.log.Errorf("%s", l.redactor.Replace(fmt.Sprintf(format, v...)))
+}
+
+func (l *redactingLogger) Warnf(format string, v ...any) {
+	l.log.Warnf("%s", l.redactor.Replace(fmt.Sprintf(format, v...)))
+}
+
+func (l *redactingLogger) Debugf(format string, v ...any) {
+	l.log.Debugf("%s", l.redactor.Replace(fmt.Sprintf(format, v...)))
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"iter"
-	"log/slog"
 	"net/http"
 	"slices"
 	"strings"
@@ -20,10 +19,6 @@ type hhResume struct {
 	title     string
 	public    bool
 	lastBoost time.Time
-
-	// piggybacking the XSRF token onto the resume
-	// We must use the same XSRF token when boosting the resume so this is fine
-	xsrf string
 }
 
 type hhApplicantResume struct {
@@ -76,7 +71,7 @@ func getHHInitialState(doc *html.Node) string {
 }
 
 // extractResumes transforms the raw HH info structure into an array of resumes.
-func extractResumes(info *hhInfo, xsrf string) []hhResume {
+func extractResumes(info *hhInfo) []hhResume {
 	resumes := make([]hhResume, 0, len(info.ApplicantResumes))
 
 	for _, resume := range info.ApplicantResumes {
@@ -90,7 +85,6 @@ func extractResumes(info *hhInfo, xsrf string) []hhResume {
 			title:     strings.Join(titles, "; "),
 			public:    resume.Attributes.HasPublicVisibility,
 			lastBoost: time.UnixMilli(resume.Attributes.Updated),
-			xsrf:      xsrf,
 		})
 	}
 
@@ -98,43 +92,31 @@ func extractResumes(info *hhInfo, xsrf string) []hhResume {
 }
 
 // hhGetResumes retrieves and parses the resume list from HH.
-func hhGetResumes(ctx *AppContext, cl *req.Client, noAuth bool) (iter.Seq[*hhResume], error) {
-	slog.Debug("getting resume list from HH")
-
-	r := cl.R()
-	r.SetHeaders(map[string]string{
-		"Sec-Fetch-Dest": "document",
-		"Sec-Fetch-Mode": "navigate",
-		"Sec-Fetch-Site": "same-origin",
+func hhGetResumes(ctx *AppContext, sess *hhSession) (iter.Seq[*hhResume], error) {
+	ctx.Log.Debug("getting resume list from HH")
+
+	resp, err := sess.do(ctx, http.MethodGet, "/applicant/resumes?role=applicant", func(r *req.Request, _ string) {
+		r.SetHeaders(map[string]string{
+			"Sec-Fetch-Dest": "document",
+			"Sec-Fetch-Mode": "navigate",
+			"Sec-Fetch-Site": "same-origin",
+		})
 	})
-
-	resp, err := r.Get(buildHHURL(ctx, "/applicant/resumes?role=applicant"))
 	if err != nil {
-		return nil, fmt.Errorf("sending HTTP request: %w", err)
+		return nil, err
 	}
 
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
-			slog.Error("failed to close response body", "error", closeErr)
+			ctx.Log.Error("failed to close response body", "error", closeErr)
 		}
 	}()
 
-	xsrf := getXSRFToken(resp)
-	if xsrf == "" {
+	// Boosts will require the XSRF token, so fail early if HH did not provide one
+	if sess.xsrfToken(ctx) == "" {
 		return nil, errors.New("missing XSRF token")
 	}
 
-	if resp.StatusCode == http.StatusForbidden && !noAuth {
-		slog.Debug("got HTTP/403, attempting to authenticate")
-
-		authErr := hhAuthenticate(ctx, cl, xsrf)
-		if authErr != nil {
-			return nil, fmt.Errorf("authenticating in HH: %w", authErr)
-		}
-
-		return hhGetResumes(ctx, cl, true)
-	}
-
 	if !resp.IsSuccessState() {
 		return nil, fmt.Errorf("received an HTTP error: status code %v", resp.StatusCode)
 	}
@@ -144,7 +126,7 @@ func hhGetResumes(ctx *AppContext, cl *req.Client, noAuth bool) (iter.Seq[*hhRes
 		return nil, fmt.Errorf("parsing request body: %w", err)
 	}
 
-	slog.Debug("parsing resume list response body")
+	ctx.Log.Debug("parsing resume list response body")
 
 	initialState := getHHInitialState(doc)
 
@@ -154,20 +136,20 @@ func hhGetResumes(ctx *AppContext, cl *req.Client, noAuth bool) (iter.Seq[*hhRes
 		return nil, fmt.Errorf("unmarshalling HH initial state: %w", err)
 	}
 
-	slog.Info("extracted HH account info", "email", info.Account.Email, "name", info.Account.FirstName+" "+info.Account.LastName)
-	slog.Debug("extracting resumes", "num_resumes", len(info.ApplicantResumes))
+	ctx.Log.Info("extracted HH account info", "email", info.Account.Email, "name", info.Account.FirstName+" "+info.Account.LastName)
+	ctx.Log.Debug("extracting resumes", "num_resumes", len(info.ApplicantResumes))
 
-	resumes := extractResumes(&info, xsrf)
+	resumes := extractResumes(&info)
 
 	return func(yield func(*hhResume) bool) {
 		for _, resume := range resumes {
 			eligible := calculateResumeEligibility(ctx, &resume)
 			if !eligible {
-				slog.Warn("ignoring resume due to eligibility constraints", "id", resume.id, "title", resume.title)
+				ctx.Log.Warn("ignoring resume due to eligibility constraints", "id", resume.id, "title", resume.title)
 				continue
 			}
 
-			slog.Info("discovered resume", "id", resume.id, "title", resume.title)
+			ctx.Log.Info("discovered resume", "id", resume.id, "title", resume.title)
 			if !yield(&resume) {
 				return
 			}
@@ -1,56 +1,541 @@
 package main
 
 import (
-	"log/slog"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
+)
+
+var (
+	errResumeNotScheduled = errors.New("resume is not scheduled")
 
-	"github.com/imroc/req/v3"
+	// errBoostSuperseded is returned if a resume has already been boosted
+	// by someone else (i.e. manually) while a scheduled boost was waiting for its turn
+	errBoostSuperseded = errors.New("resume has already been boosted in the meantime")
 )
 
+// boostStaggeredError is returned if a scheduled boost has to be delayed
+// in order to keep it apart from the previous boost.
+type boostStaggeredError struct {
+	wait time.Duration
+}
+
+func (e *boostStaggeredError) Error() string {
+	return fmt.Sprintf("boost has to be delayed by %v to keep the boosts apart", e.wait)
+}
+
+// scheduledResume is a resume that has a boost goroutine attached to it.
+type scheduledResume struct {
+	// resume may be updated by rediscovery while the boost goroutine is running,
+	// so it must only be accessed under mu
+	resume *hhResume
+	mu     sync.Mutex
+
+	// stopCh is closed when the resume gets evicted from the scheduler
+	stopCh chan struct{}
+
+	// updateCh receives a notification whenever the next boost time changes
+	// or the resume gets paused or resumed
+	updateCh chan struct{}
+
+	// These are guarded by mu as well
+	policy    resumePolicy
+	nextBoost time.Time
+
+	// plan is the boost time that follows from the last boost, before it is moved to now if overdue;
+	// jitter is drawn for it once. Both are guarded by mu
+	plan   time.Time
+	jitter time.Duration
+
+	// paused is set through the admin API, as opposed to the policy
+	paused bool
+
+	// suspended is set if the boosts have failed too many times in a row;
+	// it is lifted by resuming the resume through the admin API or by a successful manual boost
+	suspended bool
+}
+
+// resumeStatus describes a scheduled resume.
+type resumeStatus struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Public    bool      `json:"public"`
+	LastBoost time.Time `json:"last_boost,omitzero"`
+	NextBoost time.Time `json:"next_boost,omitzero"`
+	Paused    bool      `json:"paused"`
+	Suspended bool      `json:"suspended"`
+	Priority  int       `json:"priority"`
+}
+
+// snapshot returns a copy of the resume which is safe to use without holding the lock.
+func (entry *scheduledResume) snapshot() hhResume {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	return *entry.resume
+}
+
+// currentPolicy returns the policy that the resume is currently boosted with.
+func (entry *scheduledResume) currentPolicy() resumePolicy {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	return entry.policy
+}
+
+// update refreshes the resume with the data from a fresh discovery pass
+// and notifies the boost goroutine if the last boost time has advanced or the policy has changed.
+// The last boost time never moves backwards: HH may lag behind a boost that we have just made.
+func (entry *scheduledResume) update(fresh *hhResume, policy resumePolicy) {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	advanced := fresh.lastBoost.After(entry.resume.lastBoost)
+	changed := advanced || entry.policy != policy
+
+	entry.resume.title = fresh.title
+	entry.resume.public = fresh.public
+	entry.policy = policy
+
+	if advanced {
+		entry.resume.lastBoost = fresh.lastBoost
+	}
+
+	if changed {
+		entry.notify()
+	}
+}
+
+// notify wakes up the boost goroutine, so that it re-evaluates the schedule.
+func (entry *scheduledResume) notify() {
+	// Non-blocking send: a pending notification is already enough to re-arm the timer
+	select {
+	case entry.updateCh <- struct{}{}:
+	default:
+	}
+}
+
+// status returns the current status of the resume.
+func (entry *scheduledResume) status() resumeStatus {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	return resumeStatus{
+		ID:        entry.resume.id,
+		Title:     entry.resume.title,
+		Public:    entry.resume.public,
+		LastBoost: entry.resume.lastBoost,
+		NextBoost: entry.nextBoost,
+		Paused:    entry.paused || entry.policy.paused,
+		Suspended: entry.suspended,
+		Priority:  entry.policy.priority,
+	}
+}
+
+func (entry *scheduledResume) setNextBoost(t time.Time) {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	entry.nextBoost = t
+}
+
+// replan records the plan of the next boost and reports whether it differs from the previous one.
+// The jitter is drawn once per plan, so that the retries of a boost do not look like new plans.
+func (entry *scheduledResume) replan(plan time.Time, jitter func() time.Duration) (time.Duration, bool) {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if !entry.plan.IsZero() && entry.plan.Equal(plan) {
+		return entry.jitter, false
+	}
+
+	entry.plan = plan
+	entry.jitter = jitter()
+
+	return entry.jitter, true
+}
+
+// isPaused checks whether the resume is paused either through the admin API or by its policy.
+func (entry *scheduledResume) isPaused() bool {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	return entry.paused || entry.policy.paused
+}
+
+// isSuspended checks whether the boosts have been suspended due to repeated failures.
+func (entry *scheduledResume) isSuspended() bool {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	return entry.suspended
+}
+
+// setPaused pauses or resumes the boosts of the resume.
+// Resuming also lifts the suspension.
+func (entry *scheduledResume) setPaused(paused bool) {
+	entry.mu.Lock()
+	entry.paused = paused
+	if !paused {
+		entry.suspended = false
+	}
+	entry.mu.Unlock()
+
+	entry.notify()
+}
+
+// markBoosted records a successful boost.
+func (entry *scheduledResume) markBoosted(t time.Time) {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	entry.resume.lastBoost = t
+	entry.suspended = false
+}
+
+func (entry *scheduledResume) suspend() {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	entry.suspended = true
+}
+
 type resumeScheduler struct {
-	resumes  map[string]*hhResume
+	resumes  map[string]*scheduledResume
 	resumeMu sync.Mutex
 
 	stopCh chan struct{}
 
-	boostMu sync.Mutex
+	// paused suspends all boosts; guarded by resumeMu
+	paused bool
+
+	// boostLock serializes the boosts, both scheduled and manual ones;
+	// resumes with higher priority get their turn first
+	boostLock priorityLock
+
+	// lastBoost and lastBoostID describe the last successful boost of any resume; guarded by boostLock
+	lastBoost   time.Time
+	lastBoostID string
+
+	// state persists the boost history across restarts
+	state *stateStore
+
+	// windows restricts the boosts to specific time windows; nil if the boosts are not restricted
+	windows *boostWindows
+
+	// holidays are the days when the boosts are skipped or reduced; nil if there are none
+	holidays *holidayCalendar
+
+	// overrides customize the policies of specific resumes
+	overrides []resumeOverride
 }
 
-func newResumeScheduler() *resumeScheduler {
+func newResumeScheduler(state *stateStore, windows *boostWindows, holidays *holidayCalendar, overrides []resumeOverride) *resumeScheduler {
 	return &resumeScheduler{
-		resumes: map[string]*hhResume{},
-		stopCh:  make(chan struct{}),
+		resumes:   map[string]*scheduledResume{},
+		stopCh:    make(chan struct{}),
+		state:     state,
+		windows:   windows,
+		holidays:  holidays,
+		overrides: overrides,
 	}
 }
 
-func (sched *resumeScheduler) schedule(ctx *AppContext, cl *req.Client, resume *hhResume) {
+// reconcile synchronizes the set of scheduled resumes with the result of a discovery pass:
+// new resumes are scheduled, and resumes that are missing from the list
+// (either because they were removed from HH or because they have become ineligible) are evicted.
+func (sched *resumeScheduler) reconcile(ctx *AppContext, sess *hhSession, resumes []*hhResume) {
 	sched.resumeMu.Lock()
 	defer sched.resumeMu.Unlock()
 
-	if _, ok := sched.resumes[resume.id]; ok {
-		slog.Debug("resume already scheduled, ignoring", "id", resume.id, "title", resume.title)
+	discovered := make(map[string]struct{}, len(resumes))
+	for _, resume := range resumes {
+		discovered[resume.id] = struct{}{}
+	}
+
+	for id, entry := range sched.resumes {
+		if _, ok := discovered[id]; ok {
+			continue
+		}
+
+		resume := entry.snapshot()
+		ctx.Log.Info("evicting resume that is no longer available or eligible", "id", id, "title", resume.title)
+		close(entry.stopCh)
+		delete(sched.resumes, id)
+
+		if err := sched.state.remove(id); err != nil {
+			ctx.Log.Error("failed to save boost state", "error", err)
+		}
+
+		ctx.Metrics.forgetResume(ctx.Cfg.Name, id)
+	}
+
+	for _, resume := range resumes {
+		sched.schedule(ctx, sess, resume)
+	}
+}
+
+// schedule starts a boost goroutine for the resume.
+// If the resume is already scheduled, its data and policy get refreshed instead.
+// The caller must hold resumeMu.
+func (sched *resumeScheduler) schedule(ctx *AppContext, sess *hhSession, resume *hhResume) {
+	policy := resolveResumePolicy(ctx, sched.overrides, sched.windows, resume)
+
+	if entry, ok := sched.resumes[resume.id]; ok {
+		ctx.Log.Debug("resume already scheduled, refreshing", "id", resume.id, "title", resume.title)
+		entry.update(resume, policy)
 		return
 	}
 
-	sched.resumes[resume.id] = resume
+	sched.restoreState(ctx, resume)
+
+	entry := &scheduledResume{
+		resume:   resume,
+		policy:   policy,
+		stopCh:   make(chan struct{}),
+		updateCh: make(chan struct{}, 1),
+	}
+	sched.resumes[resume.id] = entry
 
 	// Start a goroutine to handle the boost
-	go sched.waitAndBoost(ctx, cl, resume)
+	go sched.waitAndBoost(ctx, sess, entry)
+}
+
+// restoreState reconciles a newly discovered resume with its persisted boost state.
+func (sched *resumeScheduler) restoreState(ctx *AppContext, resume *hhResume) {
+	rs, ok := sched.state.get(resume.id)
+	if !ok || rs.LastSuccess.IsZero() {
+		return
+	}
+
+	switch {
+	case rs.LastSuccess.After(resume.lastBoost):
+		// HH may lag behind a bit; our own record is more accurate in this case
+		ctx.Log.Debug("using persisted boost time", "id", resume.id, "title", resume.title, "last_boost", rs.LastSuccess)
+		resume.lastBoost = rs.LastSuccess
+
+	case resume.lastBoost.Sub(rs.LastSuccess) > time.Minute:
+		ctx.Log.Info("resume has been updated outside of the tool since our last boost",
+			"id", resume.id, "title", resume.title, "our_last_boost", rs.LastSuccess, "hh_last_update", resume.lastBoost)
+	}
 }
 
-func (sched *resumeScheduler) waitAndBoost(ctx *AppContext, cl *req.Client, resume *hhResume) {
+// list returns the statuses of all scheduled resumes, ordered by their next boost time.
+func (sched *resumeScheduler) list() []resumeStatus {
+	sched.resumeMu.Lock()
+	defer sched.resumeMu.Unlock()
+
+	statuses := make([]resumeStatus, 0, len(sched.resumes))
+	for _, entry := range sched.resumes {
+		statuses = append(statuses, entry.status())
+	}
+
+	slices.SortFunc(statuses, func(a, b resumeStatus) int {
+		if c := a.NextBoost.Compare(b.NextBoost); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return statuses
+}
+
+// lookup finds a scheduled resume by its ID.
+func (sched *resumeScheduler) lookup(id string) (*scheduledResume, error) {
+	sched.resumeMu.Lock()
+	defer sched.resumeMu.Unlock()
+
+	entry, ok := sched.resumes[id]
+	if !ok {
+		return nil, errResumeNotScheduled
+	}
+
+	return entry, nil
+}
+
+// isPaused checks whether the whole scheduler is paused.
+func (sched *resumeScheduler) isPaused() bool {
+	sched.resumeMu.Lock()
+	defer sched.resumeMu.Unlock()
+
+	return sched.paused
+}
+
+// setPaused pauses or resumes all boosts.
+func (sched *resumeScheduler) setPaused(paused bool) {
+	sched.resumeMu.Lock()
+	defer sched.resumeMu.Unlock()
+
+	sched.paused = paused
+	for _, entry := range sched.resumes {
+		entry.notify()
+	}
+}
+
+// setResumePaused pauses or resumes the boosts of a single resume.
+func (sched *resumeScheduler) setResumePaused(id string, paused bool) error {
+	entry, err := sched.lookup(id)
+	if err != nil {
+		return err
+	}
+
+	entry.setPaused(paused)
+	return nil
+}
+
+// boostNow immediately boosts a scheduled resume, regardless of its schedule or pause state.
+func (sched *resumeScheduler) boostNow(ctx *AppContext, sess *hhSession, id string) error {
+	entry, err := sched.lookup(id)
+	if err != nil {
+		return err
+	}
+
+	err = sched.exclusiveBoost(ctx, sess, entry, nil)
+	if err == nil {
+		// Let the boost goroutine re-arm its timer
+		entry.notify()
+	}
+
+	return err
+}
+
+// recordState updates the persisted boost state of a resume.
+func (sched *resumeScheduler) recordState(ctx *AppContext, id string, fn func(rs *resumeState)) {
+	err := sched.state.update(id, fn)
+	if err != nil {
+		ctx.Log.Error("failed to save boost state", "error", err)
+	}
+}
+
+// maxPlanSteps limits the number of adjustments of a boost time to the windows and holidays.
+// Every adjustment moves the boost time forward, and a couple of them is normally enough.
+const maxPlanSteps = 32
+
+// planBoost returns the time of the next boost of the resume,
+// and reports whether it is a new plan rather than a retry of the previous one.
+func (sched *resumeScheduler) planBoost(ctx *AppContext, entry *scheduledResume, resume *hhResume, policy *resumePolicy) (time.Time, bool) {
+	now := time.Now()
+	due := resume.lastBoost.Add(policy.interval)
+	planned := sched.nextBoostTime(policy, resume.lastBoost, now, ctx.Cfg.Holidays.BoostInterval)
+
+	// Unlike the planned time of an overdue boost, the plan does not depend on the current time,
+	// so it stays the same across the retries
+	plan := sched.nextBoostTime(policy, resume.lastBoost, time.Time{}, ctx.Cfg.Holidays.BoostInterval)
+	delay, replanned := entry.replan(plan, ctx.Jitter.delay)
+
+	if planned.After(due) && planned.After(now) {
+		ctx.Log.Debug("deferring resume boost", "id", resume.id, "title", resume.title, "due", due, "boost_time", planned)
+	}
+
+	// Jitter must not push the boost out of its window
+	jittered := planned.Add(delay)
+	if policy.windows != nil && !policy.windows.next(jittered).Equal(jittered) {
+		return planned, replanned
+	}
+
+	return jittered, replanned
+}
+
+// nextBoostTime returns the time of the boost that follows the last one,
+// taking the boost windows of the policy and the holidays into account.
+// Overdue boosts are planned from now on.
+func (sched *resumeScheduler) nextBoostTime(policy *resumePolicy, lastBoost, now time.Time, holidayInterval time.Duration) time.Time {
+	t := lastBoost.Add(policy.interval)
+	if policy.windows == nil && sched.holidays == nil {
+		return t
+	}
+
+	for range maxPlanSteps {
+		if t.Before(now) {
+			t = now
+		}
+
+		if policy.windows != nil {
+			t = policy.windows.next(t)
+		}
+
+		if sched.holidays == nil || !sched.holidays.contains(t) {
+			break
+		}
+
+		next := sched.holidays.nextWorkday(t)
+
+		// A reduced boost interval allows boosting on holidays, but less often
+		if holidayInterval > 0 {
+			reduced := lastBoost.Add(holidayInterval)
+			if !reduced.After(t) {
+				break
+			}
+
+			if reduced.Before(next) {
+				next = reduced
+			}
+		}
+
+		t = next
+	}
+
+	return t
+}
+
+// replan makes the boost goroutines re-evaluate their schedules,
+// e.g. after the holiday calendar has changed.
+func (sched *resumeScheduler) replan() {
+	sched.resumeMu.Lock()
+	defer sched.resumeMu.Unlock()
+
+	for _, entry := range sched.resumes {
+		entry.notify()
+	}
+}
+
+func (sched *resumeScheduler) waitAndBoost(ctx *AppContext, sess *hhSession, entry *scheduledResume) {
+	// failures is the number of consecutive failures of the class failureClass
+	var (
+		failures     int
+		failureClass boostErrorClass
+	)
+
 	for {
-		nextBoostTime := resume.lastBoost.Add(ctx.Cfg.BoostInterval)
+		resume := entry.snapshot()
+		policy := entry.currentPolicy()
+		nextBoostTime, replanned := sched.planBoost(ctx, entry, &resume, &policy)
+
+		entry.setNextBoost(nextBoostTime)
+		sched.recordState(ctx, resume.id, func(rs *resumeState) {
+			rs.NextBoost = nextBoostTime
+		})
+		ctx.Metrics.nextBoost.WithLabelValues(ctx.Cfg.Name, resume.id).Set(float64(nextBoostTime.Unix()))
+
+		// Retries and wakeups that do not change the plan are not worth a notification
+		if replanned {
+			ctx.Notifier.publish(ctx, event{
+				Kind:        eventBoostScheduled,
+				ResumeID:    resume.id,
+				ResumeTitle: resume.title,
+				NextBoost:   nextBoostTime,
+			})
+		}
 
 		// If we have not yet reached the deadline, wait a bit
 		now := time.Now()
 		if nextBoostTime.After(now) {
-			slog.Info("scheduling resume boost", "id", resume.id, "title", resume.title, "boost_time", nextBoostTime)
+			ctx.Log.Info("scheduling resume boost", "id", resume.id, "title", resume.title, "boost_time", nextBoostTime)
 
 			timer := time.NewTimer(nextBoostTime.Sub(now))
 			select {
 			case <-timer.C:
+			case <-entry.updateCh:
+				// The resume has been boosted elsewhere (or HH reports a different boost time),
+				// so the timer has to be re-armed
+				timer.Stop()
+				continue
+			case <-entry.stopCh:
+				return
 			case <-sched.stopCh:
 				return
 			case <-ctx.Done():
@@ -58,14 +543,59 @@ func (sched *resumeScheduler) waitAndBoost(ctx *AppContext, cl *req.Client, resu
 			}
 		}
 
-		err := sched.exclusiveBoost(ctx, cl, resume)
-		if err != nil {
-			// wait a bit and retry
-			slog.Info("failed to boost resume, will schedule another attempt", "error", err.Error(), "wait_for", ctx.Cfg.BoostBackoffDelay)
-			timer := time.NewTimer(ctx.Cfg.BoostBackoffDelay)
+		// A paused or suspended resume stays due until it gets resumed
+		if sched.isPaused() || entry.isPaused() || entry.isSuspended() {
+			ctx.Log.Info("resume boost is paused", "id", resume.id, "title", resume.title, "suspended", entry.isSuspended())
+
+			select {
+			case <-entry.updateCh:
+				continue
+			case <-entry.stopCh:
+				return
+			case <-sched.stopCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// Boosts are not attempted while HH is down
+		select {
+		case <-ctx.Breaker.ready():
+		default:
+			ctx.Log.Info("waiting for HH to come back before boosting", "id", resume.id, "title", resume.title)
+
+			select {
+			case <-ctx.Breaker.ready():
+				continue
+			case <-entry.updateCh:
+				continue
+			case <-entry.stopCh:
+				return
+			case <-sched.stopCh:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		err := sched.exclusiveBoost(ctx, sess, entry, &resume.lastBoost)
+		if errors.Is(err, errBoostSuperseded) || errors.Is(err, errCircuitOpen) {
+			continue
+		}
+
+		var staggered *boostStaggeredError
+		if errors.As(err, &staggered) {
+			ctx.Log.Debug("delaying resume boost to keep the boosts apart", "id", resume.id, "title", resume.title, "wait_for", staggered.wait)
+			timer := time.NewTimer(staggered.wait)
 			select {
 			case <-timer.C:
 				continue
+			case <-entry.updateCh:
+				timer.Stop()
+				continue
+			case <-entry.stopCh:
+				return
 			case <-sched.stopCh:
 				return
 			case <-ctx.Done():
@@ -73,15 +603,171 @@ func (sched *resumeScheduler) waitAndBoost(ctx *AppContext, cl *req.Client, resu
 			}
 		}
 
-		resume.lastBoost = time.Now()
+		if err == nil {
+			failures = 0
+			continue
+		}
+
+		class, retryAfter := classifyBoostError(err)
+		if class != failureClass {
+			failures = 0
+			failureClass = class
+		}
+
+		failures++
+		retry := ctx.Cfg.boostRetryPolicy(class)
+
+		if retry.MaxAttempts > 0 && failures >= retry.MaxAttempts {
+			sched.suspend(ctx, entry, &resume, failures, err)
+			failures = 0
+			continue
+		}
+
+		// wait a bit and retry
+		backoffDelay := ctx.Jitter.apply(boostRetryDelay(retry, failures, retryAfter))
+		ctx.Log.Info("failed to boost resume, will schedule another attempt",
+			"error", err.Error(), "class", class, "failures", failures, "wait_for", backoffDelay)
+		timer := time.NewTimer(backoffDelay)
+		select {
+		case <-timer.C:
+		case <-entry.updateCh:
+			timer.Stop()
+		case <-entry.stopCh:
+			return
+		case <-sched.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// exclusiveBoost boosts the resume and records the outcome.
+// Boosts are serialized by boostLock, so that manual boosts cannot race scheduled ones.
+//
+// If since is not nil, the boost is a scheduled one: it is skipped with errBoostSuperseded
+// if the resume has been boosted after that time, and it is delayed with boostStaggeredError
+// if it would happen too soon after the previous boost of another resume.
+func (sched *resumeScheduler) exclusiveBoost(ctx *AppContext, sess *hhSession, entry *scheduledResume, since *time.Time) error {
+	sched.boostLock.lock(entry.currentPolicy().priority)
+	defer sched.boostLock.unlock()
+
+	resume := entry.snapshot()
+	if since != nil && !resume.lastBoost.Equal(*since) {
+		return errBoostSuperseded
+	}
+
+	if since != nil && sched.lastBoostID != resume.id {
+		if wait := time.Until(sched.lastBoost.Add(sched.staggerGap(ctx))); wait > 0 {
+			return &boostStaggeredError{wait: wait}
+		}
+	}
+
+	// The breaker may have opened while the boost was waiting for its turn
+	select {
+	case <-ctx.Breaker.ready():
+	default:
+		return errCircuitOpen
+	}
+
+	ctx.Metrics.boostAttempts.WithLabelValues(ctx.Cfg.Name, resume.id).Inc()
+
+	err := hhBoostResume(ctx, sess, &resume)
+	attemptTime := time.Now()
+
+	// Another request has become the probe of the half-open breaker in the meantime
+	if errors.Is(err, errCircuitOpen) {
+		return err
+	}
+
+	result := boostResultSucceeded
+	switch {
+	case errors.Is(err, ErrBoostTooEarly):
+		result = boostResultTooEarly
+	case err != nil:
+		result = boostResultFailed
+	}
+
+	ctx.Metrics.boosts.WithLabelValues(ctx.Cfg.Name, resume.id, result).Inc()
+
+	failures := 0
+	sched.recordState(ctx, resume.id, func(rs *resumeState) {
+		rs.LastAttempt = attemptTime
+		if err != nil {
+			rs.Failures++
+			failures = rs.Failures
+			return
+		}
+
+		rs.LastSuccess = attemptTime
+		rs.Failures = 0
+	})
+
+	if err != nil {
+		// Only notify once per streak of failures
+		if failures == ctx.Cfg.Notifications.BoostFailureThreshold {
+			ctx.Notifier.publish(ctx, event{
+				Kind:        eventBoostFailing,
+				ResumeID:    resume.id,
+				ResumeTitle: resume.title,
+				Failures:    failures,
+				Error:       err.Error(),
+			})
+		}
+
+		return err
 	}
+
+	entry.markBoosted(attemptTime)
+	sched.lastBoost = attemptTime
+	sched.lastBoostID = resume.id
+
+	return nil
 }
 
-func (sched *resumeScheduler) exclusiveBoost(ctx *AppContext, cl *req.Client, resume *hhResume) error {
-	sched.boostMu.Lock()
-	defer sched.boostMu.Unlock()
+// suspend stops the boosts of a resume that has failed too many times in a row
+// and notifies the user about it.
+func (sched *resumeScheduler) suspend(ctx *AppContext, entry *scheduledResume, resume *hhResume, failures int, err error) {
+	ctx.Log.Warn("suspending resume boosts after repeated failures",
+		"id", resume.id, "title", resume.title, "failures", failures, "error", err.Error())
 
-	return hhBoostResume(ctx, cl, resume)
+	entry.suspend()
+	ctx.Notifier.publish(ctx, event{
+		Kind:        eventBoostSuspended,
+		ResumeID:    resume.id,
+		ResumeTitle: resume.title,
+		Failures:    failures,
+		Error:       err.Error(),
+	})
+}
+
+// staggerGap returns the minimum time between two scheduled boosts.
+// The boosts are spread across the shortest interval of the scheduled resumes,
+// so that the resumes with overridden intervals are not delayed beyond them.
+func (sched *resumeScheduler) staggerGap(ctx *AppContext) time.Duration {
+	gap := ctx.Cfg.Stagger.MinGap
+
+	if ctx.Cfg.Stagger.Spread {
+		sched.resumeMu.Lock()
+		n := len(sched.resumes)
+		interval := time.Duration(0)
+		for _, entry := range sched.resumes {
+			if policy := entry.currentPolicy(); policy.interval > 0 && (interval == 0 || policy.interval < interval) {
+				interval = policy.interval
+			}
+		}
+		sched.resumeMu.Unlock()
+
+		if interval == 0 {
+			interval = ctx.Cfg.BoostInterval
+		}
+
+		if n > 0 {
+			gap = max(gap, interval/time.Duration(n))
+		}
+	}
+
+	return gap
 }
 
 func (sched *resumeScheduler) done() <-chan struct{} {
@@ -96,8 +782,9 @@ func (sched *resumeScheduler) done() <-chan struct{} {
 		return ch
 	}
 
-	// If there ARE resumes, the scheduler will never evict them,
-	// since it will infinitely try to re-schedule a resume
+	// If there ARE resumes, the scheduler will not evict them anymore
+	// (eviction only happens during discovery, which has already finished by now),
+	// and it will infinitely try to re-schedule a resume
 	// even if its boost fails for any reason.
 	//
 	// Thus, we return a non-closed channel, so that the
@@ -105,6 +792,23 @@ func (sched *resumeScheduler) done() <-chan struct{} {
 	return make(chan struct{})
 }
 
+// responsive checks that the scheduler lock can be acquired within the timeout.
+// It is used by the liveness check to detect deadlocks.
+func (sched *resumeScheduler) responsive(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	for !sched.resumeMu.TryLock() {
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sched.resumeMu.Unlock()
+	return true
+}
+
 func (sched *resumeScheduler) teardown() {
 	close(sched.stopCh)
 }
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestScheduledResumeUpdate checks that a lagging discovery pass does not move the last boost time backwards.
+func TestScheduledResumeUpdate(t *testing.T) {
+	lastBoost := time.Now()
+
+	entry := &scheduledResume{
+		resume:   &hhResume{id: "abc", title: "test", lastBoost: lastBoost},
+		updateCh: make(chan struct{}, 1),
+	}
+
+	isNotified := func() bool {
+		select {
+		case <-entry.updateCh:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// HH has not caught up with our boost yet
+	entry.update(&hhResume{id: "abc", title: "renamed", lastBoost: lastBoost.Add(-4 * time.Hour)}, resumePolicy{})
+
+	if resume := entry.snapshot(); !resume.lastBoost.Equal(lastBoost) || resume.title != "renamed" {
+		t.Errorf("invalid resume: got %v (%q), expected %v (%q)", resume.lastBoost, resume.title, lastBoost, "renamed")
+	}
+
+	if isNotified() {
+		t.Error("boost goroutine has been notified although the last boost time has not advanced")
+	}
+
+	// The resume has been boosted outside of the tool
+	entry.update(&hhResume{id: "abc", title: "renamed", lastBoost: lastBoost.Add(time.Hour)}, resumePolicy{})
+
+	if resume := entry.snapshot(); !resume.lastBoost.Equal(lastBoost.Add(time.Hour)) {
+		t.Errorf("invalid last boost time: got %v, expected %v", resume.lastBoost, lastBoost.Add(time.Hour))
+	}
+
+	if !isNotified() {
+		t.Error("boost goroutine has not been notified")
+	}
+}
+
+// TestBoostScheduledOnce checks that the retries of an overdue boost do not announce it again,
+// even though the jitter and the current time change the boost time of every retry.
+func TestBoostScheduledOnce(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Context = t.Context()
+	ctx.Cfg.Jitter.Distribution = jitterUniform
+	ctx.Cfg.Jitter.Min = time.Millisecond
+	ctx.Cfg.Jitter.Max = 5 * time.Millisecond
+	ctx.Jitter = newJitter(&ctx.Cfg)
+	ctx.Cfg.BoostRetry.NotFound = BoostRetryPolicy{
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     10 * time.Millisecond,
+		MaxAttempts:  3,
+	}
+
+	// The boosts are allowed at any time, but overdue boosts are moved to now
+	ctx.Cfg.BoostWindows.TimeZone = "UTC"
+	ctx.Cfg.BoostWindows.Windows = []BoostWindow{{Start: "00:00", End: "24:00"}}
+
+	windows, err := parseBoostWindows(&ctx.Cfg)
+	if err != nil {
+		t.Fatalf("parsing boost windows: %v", err)
+	}
+
+	recorder := &eventRecorder{events: make(chan *event, notificationQueueSize)}
+	ctx.Notifier.add(recorder, []eventKind{eventBoostScheduled, eventBoostSuspended})
+	ctx.Notifier.run(ctx)
+
+	state, err := loadStateStore("")
+	if err != nil {
+		t.Fatalf("loading state: %v", err)
+	}
+
+	sess := newHHSession(createHTTPClient(ctx), nil, nil)
+	sched := newResumeScheduler(state, windows, nil, nil)
+	sched.reconcile(ctx, sess, []*hhResume{{id: "abc", title: "test"}})
+
+	scheduled := 0
+	for suspended := false; !suspended; {
+		select {
+		case ev := <-recorder.events:
+			if ev.Kind == eventBoostScheduled {
+				scheduled++
+			}
+
+			suspended = ev.Kind == eventBoostSuspended
+		case <-time.After(5 * time.Second):
+			t.Fatal("resume has not been suspended")
+		}
+	}
+
+	// The suspended resume is planned once more before it starts waiting
+	time.Sleep(50 * time.Millisecond)
+	sched.teardown()
+
+	for len(recorder.events) > 0 {
+		if ev := <-recorder.events; ev.Kind == eventBoostScheduled {
+			scheduled++
+		}
+	}
+
+	if scheduled != 1 {
+		t.Errorf("invalid number of boost_scheduled events: got %v, expected 1", scheduled)
+	}
+}
+
+// TestReconcileEviction checks that the resumes that have disappeared from HH are evicted:
+// their boost goroutines are stopped, and their state and metrics are forgotten.
+func TestReconcileEviction(t *testing.T) {
+	srv, _ := newFakeHHServer(t)
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Cfg.Name = "test"
+	ctx.Context = t.Context()
+
+	state, err := loadStateStore("")
+	if err != nil {
+		t.Fatalf("loading state: %v", err)
+	}
+
+	lastBoost := time.Now().Add(-time.Hour)
+	for _, id := range []string{"abc", "def"} {
+		if err := state.update(id, func(rs *resumeState) { rs.LastSuccess = lastBoost }); err != nil {
+			t.Fatalf("saving state: %v", err)
+		}
+	}
+
+	sess := newHHSession(createHTTPClient(ctx), nil, nil)
+	sched := newResumeScheduler(state, nil, nil, nil)
+	defer sched.teardown()
+
+	sched.reconcile(ctx, sess, []*hhResume{
+		{id: "abc", title: "first", lastBoost: lastBoost},
+		{id: "def", title: "second", lastBoost: lastBoost},
+	})
+
+	scrape := func() string {
+		t.Helper()
+
+		mux := http.NewServeMux()
+		ctx.Metrics.register(mux)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequestWithContext(t.Context(), http.MethodGet, "/metrics", nil))
+
+		return rec.Body.String()
+	}
+
+	// Wait until both boost goroutines have planned their boosts
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(scrape(), `resume="abc"`) || !strings.Contains(scrape(), `resume="def"`) {
+		if time.Now().After(deadline) {
+			t.Fatal("resumes have not been scheduled")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sched.resumeMu.Lock()
+	evicted := sched.resumes["abc"]
+	sched.resumeMu.Unlock()
+
+	sched.reconcile(ctx, sess, []*hhResume{{id: "def", title: "second", lastBoost: lastBoost}})
+
+	if statuses := sched.list(); len(statuses) != 1 || statuses[0].ID != "def" {
+		t.Fatalf("invalid scheduled resumes: %+v", statuses)
+	}
+
+	select {
+	case <-evicted.stopCh:
+	default:
+		t.Error("boost goroutine of the evicted resume has not been stopped")
+	}
+
+	if _, ok := state.get("abc"); ok {
+		t.Error("state of the evicted resume has not been removed")
+	}
+
+	if _, ok := state.get("def"); !ok {
+		t.Error("state of the remaining resume has been removed")
+	}
+
+	// A stopped goroutine does not plan the boost again, even if it is woken up
+	evicted.notify()
+	time.Sleep(50 * time.Millisecond)
+
+	metrics := scrape()
+	if strings.Contains(metrics, `resume="abc"`) {
+		t.Error("metrics of the evicted resume have not been forgotten")
+	}
+
+	if !strings.Contains(metrics, `resume="def"`) {
+		t.Error("metrics of the remaining resume have been forgotten")
+	}
+}
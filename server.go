@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// listen creates a listener for the address,
+// which is either a TCP address (host:port) or a unix socket path prefixed with "unix:".
+func listen(ctx context.Context, address string) (net.Listener, error) {
+	lc := net.ListenConfig{}
+
+	if path, ok := strings.CutPrefix(address, "unix:"); ok {
+		// Remove a stale socket that may have been left over from a previous run
+		if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			err = os.Remove(path)
+			if err != nil {
+				return nil, fmt.Errorf("removing stale unix socket: %w", err)
+			}
+		}
+
+		return lc.Listen(ctx, "unix", path)
+	}
+
+	return lc.Listen(ctx, "tcp", address)
+}
+
+// serveHTTP starts serving the handler on the address in background.
+// The server is shut down when the context is cancelled.
+func serveHTTP(ctx context.Context, name, address string, handler http.Handler) error {
+	l, err := listen(ctx, address)
+	if err != nil {
+		return fmt.Errorf("listening on %v: %w", address, err)
+	}
+
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			slog.Error("failed to shut down HTTP server", "server", name, "error", err)
+		}
+	}()
+
+	go func() {
+		slog.Info("started HTTP server", "server", name, "address", address)
+
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server has failed", "server", name, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// writeJSON writes a JSON-encoded HTTP response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Debug("failed to write HTTP response", "error", err)
+	}
+}
+
+// writeJSONError writes an error in the form of a JSON object.
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
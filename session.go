@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// authRetryDelay is the minimum delay between two consecutive re-authentication attempts
+// after a failed one. It prevents us from hammering the HH login endpoint
+// when several requests detect an expired session at once.
+const authRetryDelay = time.Minute
+
+// errAuthenticationFailed is returned by hhSession.do if the session has expired
+// and it could not be renewed.
+var errAuthenticationFailed = errors.New("authenticating in HH")
+
+// hhSession wraps a req.Client and keeps the HH session alive:
+// requests that fail due to an expired session are transparently retried after a re-login.
+type hhSession struct {
+	cl *req.Client
+
+	// otp provides one-time codes if HH requires a two-step login; may be nil
+	otp answerSource
+
+	// captcha hands captchas off to a human; may be nil
+	captcha answerSource
+
+	// authMu guards the fields below. It is never held while logging in,
+	// since a login may wait for a human to solve a captcha or to enter a one-time code
+	authMu sync.Mutex
+
+	// authGen is incremented on every successful authentication;
+	// it is used to detect whether the session has already been renewed by someone else
+	authGen uint64
+
+	// login is the authentication in progress, which the other requests wait for; nil if there is none
+	login *authAttempt
+
+	lastAuthAttempt time.Time
+	lastAuthErr     error
+}
+
+// authAttempt is an authentication that is shared by all requests that have found the session expired.
+type authAttempt struct {
+	// done is closed once the attempt has finished, with err being its outcome
+	done chan struct{}
+	err  error
+}
+
+func newHHSession(cl *req.Client, otp, captcha answerSource) *hhSession {
+	return &hhSession{
+		cl:      cl,
+		otp:     otp,
+		captcha: captcha,
+	}
+}
+
+// do sends a request to HH.
+// The request is built by prepare, which receives the current XSRF token;
+// prepare may be called more than once, since the request is rebuilt on retries.
+//
+// If HH reports that the session has expired, do re-authenticates
+// and retries the request once.
+func (sess *hhSession) do(ctx *AppContext, method, pathWithQuery string, prepare func(r *req.Request, xsrf string)) (*req.Response, error) {
+	for retried := false; ; retried = true {
+		gen := sess.generation()
+
+		r := sess.cl.R()
+		prepare(r, sess.xsrfToken(ctx))
+
+		resp, err := r.Send(method, buildHHURL(ctx, pathWithQuery))
+		if err != nil {
+			return nil, fmt.Errorf("sending HTTP request: %w", err)
+		}
+
+		if retried || !isSessionExpired(resp) {
+			return resp, nil
+		}
+
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			ctx.Log.Error("failed to close response body", "error", closeErr)
+		}
+
+		ctx.Log.Debug("HH session has expired, attempting to authenticate", "status_code", resp.StatusCode)
+
+		err = sess.reauthenticate(ctx, gen)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errAuthenticationFailed, err)
+		}
+	}
+}
+
+// xsrfToken returns the current XSRF token from the cookie jar.
+func (sess *hhSession) xsrfToken(ctx *AppContext) string {
+	cookies, err := sess.cl.GetCookies(ctx.Cfg.Endpoint)
+	if err != nil {
+		return ""
+	}
+
+	return getXSRFToken(cookies)
+}
+
+func (sess *hhSession) generation() uint64 {
+	sess.authMu.Lock()
+	defer sess.authMu.Unlock()
+
+	return sess.authGen
+}
+
+// reauthenticate logs into HH, unless the session has already been renewed
+// since the caller has observed the generation gen.
+// If another request is logging in already, it waits for the outcome of that login instead.
+func (sess *hhSession) reauthenticate(ctx *AppContext, gen uint64) error {
+	sess.authMu.Lock()
+
+	if sess.authGen != gen {
+		sess.authMu.Unlock()
+		ctx.Log.Debug("HH session has already been renewed, skipping authentication")
+		return nil
+	}
+
+	if attempt := sess.login; attempt != nil {
+		sess.authMu.Unlock()
+		ctx.Log.Debug("waiting for the authentication in progress")
+
+		select {
+		case <-attempt.done:
+			return attempt.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if sess.lastAuthErr != nil && time.Since(sess.lastAuthAttempt) < authRetryDelay {
+		err := sess.lastAuthErr
+		sess.authMu.Unlock()
+		return fmt.Errorf("previous authentication attempt has failed recently: %w", err)
+	}
+
+	attempt := &authAttempt{done: make(chan struct{})}
+	sess.login = attempt
+	sess.authMu.Unlock()
+
+	err := sess.authenticate(ctx)
+	ctx.Health.recordAuthentication(err)
+
+	sess.authMu.Lock()
+	sess.login = nil
+	sess.lastAuthAttempt = time.Now()
+	sess.lastAuthErr = err
+	if err == nil {
+		sess.authGen++
+	}
+	sess.authMu.Unlock()
+
+	attempt.err = err
+	close(attempt.done)
+
+	return err
+}
+
+// authenticate logs into HH, fetching the XSRF token first if there is none yet.
+func (sess *hhSession) authenticate(ctx *AppContext) error {
+	xsrf := sess.xsrfToken(ctx)
+	if xsrf == "" {
+		err := sess.fetchXSRFToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		xsrf = sess.xsrfToken(ctx)
+	}
+
+	return hhAuthenticate(ctx, sess, xsrf)
+}
+
+// fetchXSRFToken loads the resume page without authentication,
+// so that HH sets the XSRF cookie.
+func (sess *hhSession) fetchXSRFToken(ctx *AppContext) error {
+	ctx.Log.Debug("fetching XSRF token")
+
+	r := sess.cl.R()
+	r.SetHeaders(map[string]string{
+		"Sec-Fetch-Dest": "document",
+		"Sec-Fetch-Mode": "navigate",
+		"Sec-Fetch-Site": "same-origin",
+	})
+
+	resp, err := r.Get(buildHHURL(ctx, "/applicant/resumes?role=applicant"))
+	if err != nil {
+		return fmt.Errorf("sending HTTP request: %w", err)
+	}
+
+	if closeErr := resp.Body.Close(); closeErr != nil {
+		ctx.Log.Error("failed to close response body", "error", closeErr)
+	}
+
+	if sess.xsrfToken(ctx) == "" {
+		return fmt.Errorf("missing XSRF token (status code %v)", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// isSessionExpired checks if HH has rejected a request due to missing or expired authentication.
+func isSessionExpired(resp *req.Response) bool {
+	return resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// newFakeHHServer starts a minimal HH imitation which requires authentication for boosts.
+// The returned counter is incremented on every successful login.
+func newFakeHHServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	logins := &atomic.Int32{}
+	mux := http.NewServeMux()
+
+	authenticated := func(r *http.Request) bool {
+		c, err := r.Cookie("hhtoken")
+		return err == nil && c.Value == "ok"
+	}
+
+	mux.HandleFunc("GET /applicant/resumes", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "_xsrf", Value: "xsrf-token", Path: "/"})
+		if !authenticated(r) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		_, _ = w.Write([]byte(`<template id="HH-Lux-InitialState">{"applicantResumes": []}</template>`))
+	})
+
+	mux.HandleFunc("POST /account/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Xsrftoken") != "xsrf-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		logins.Add(1)
+		http.SetCookie(w, &http.Cookie{Name: "hhtoken", Value: "ok", Path: "/"})
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	mux.HandleFunc("POST /applicant/resumes/touch", func(w http.ResponseWriter, r *http.Request) {
+		if !authenticated(r) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		if r.Header.Get("X-Xsrftoken") != "xsrf-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv, logins
+}
+
+func newTestAppContext(t *testing.T, endpoint string) *AppContext {
+	t.Helper()
+
+	ctx := &AppContext{
+		Context:  context.Background(),
+		Log:      slog.Default(),
+		Metrics:  newAppMetrics(),
+		Health:   newAppHealth().account("test"),
+		Notifier: newNotificationHub(),
+	}
+	ctx.Cfg.Instantiate()
+	ctx.Cfg.Endpoint = endpoint
+	ctx.Cfg.Login = "+78005553535"
+	ctx.Cfg.Password = "Bash1234"
+	ctx.Cfg.CookieJarFileName = ""
+	ctx.Cfg.StateFileName = ""
+
+	return ctx
+}
+
+// TestSessionReauthenticates checks that an expired session is renewed transparently,
+// and that concurrent requests share a single login.
+func TestSessionReauthenticates(t *testing.T) {
+	srv, logins := newFakeHHServer(t)
+	ctx := newTestAppContext(t, srv.URL)
+	sess := newHHSession(createHTTPClient(ctx), nil, nil)
+
+	wg := sync.WaitGroup{}
+	for range 5 {
+		wg.Go(func() {
+			err := hhBoostResume(ctx, sess, &hhResume{id: "abc", title: "test"})
+			if err != nil {
+				t.Errorf("boosting resume: %v", err)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	if n := logins.Load(); n != 1 {
+		t.Errorf("invalid number of logins: got %v, expected 1", n)
+	}
+}
+
+// TestSessionAuthFailureCooldown checks that a failed login is not retried immediately.
+func TestSessionAuthFailureCooldown(t *testing.T) {
+	attempts := &atomic.Int32{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "_xsrf", Value: "xsrf-token", Path: "/"})
+		if r.URL.Path == "/account/login" {
+			attempts.Add(1)
+			_, _ = w.Write([]byte(`{"loginError": {"code": "INVALID_PASSWORD"}}`))
+			return
+		}
+
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := newTestAppContext(t, srv.URL)
+	sess := newHHSession(createHTTPClient(ctx), nil, nil)
+
+	for range 3 {
+		if err := hhBoostResume(ctx, sess, &hhResume{id: "abc"}); err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+	}
+
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("invalid number of login attempts: got %v, expected 1", n)
+	}
+}
+
+// TestSessionLoginDoesNotBlock checks that a login which waits for a human does not hold up
+// the requests that do not need it, and that the requests that do need it share the login.
+func TestSessionLoginDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	logins := &atomic.Int32{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /applicant/resumes", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "_xsrf", Value: "xsrf-token", Path: "/"})
+		if c, err := r.Cookie("hhtoken"); err != nil || c.Value != "ok" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	})
+	mux.HandleFunc("GET /public", func(http.ResponseWriter, *http.Request) {})
+	mux.HandleFunc("POST /account/login", func(w http.ResponseWriter, r *http.Request) {
+		// The login is stuck until a human solves the captcha
+		<-release
+
+		logins.Add(1)
+		http.SetCookie(w, &http.Cookie{Name: "hhtoken", Value: "ok", Path: "/"})
+		_, _ = w.Write([]byte(`{}`))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Context = t.Context()
+	sess := newHHSession(createHTTPClient(ctx), nil, nil)
+
+	get := func(path string) error {
+		resp, err := sess.do(ctx, http.MethodGet, path, func(*req.Request, string) {})
+		if err == nil && resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("invalid status code: %v", resp.StatusCode)
+		}
+
+		return err
+	}
+
+	wg := sync.WaitGroup{}
+	for range 3 {
+		wg.Go(func() {
+			if err := get("/applicant/resumes"); err != nil {
+				t.Errorf("sending request: %v", err)
+			}
+		})
+	}
+
+	// Wait until the login is in progress
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sess.authMu.Lock()
+		inProgress := sess.login != nil
+		sess.authMu.Unlock()
+
+		if inProgress || time.Now().After(deadline) {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- get("/public")
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("sending request: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("request has been blocked by the login in progress")
+	}
+
+	close(release)
+	wg.Wait()
+
+	if n := logins.Load(); n != 1 {
+		t.Errorf("invalid number of logins: got %v, expected 1", n)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestStaggeredBoosts checks that the scheduled boosts of different resumes are kept apart.
+func TestStaggeredBoosts(t *testing.T) {
+	srv, _ := newFakeHHServer(t)
+	ctx := newTestAppContext(t, srv.URL)
+	ctx.Cfg.Stagger.MinGap = 10 * time.Minute
+
+	sess := newHHSession(createHTTPClient(ctx), nil, nil)
+	state, err := loadStateStore("")
+	if err != nil {
+		t.Fatalf("loading state: %v", err)
+	}
+
+	sched := newResumeScheduler(state, nil, nil, nil)
+	first := &scheduledResume{resume: &hhResume{id: "abc", title: "first"}}
+	second := &scheduledResume{resume: &hhResume{id: "def", title: "second"}}
+
+	if err := sched.exclusiveBoost(ctx, sess, first, &time.Time{}); err != nil {
+		t.Fatalf("boosting first resume: %v", err)
+	}
+
+	var staggered *boostStaggeredError
+
+	err = sched.exclusiveBoost(ctx, sess, second, &time.Time{})
+	if !errors.As(err, &staggered) {
+		t.Fatalf("invalid error: got %v, expected a staggered boost", err)
+	}
+
+	if staggered.wait <= 9*time.Minute || staggered.wait > 10*time.Minute {
+		t.Errorf("invalid stagger delay: %v", staggered.wait)
+	}
+
+	// Spreading the boosts across the interval takes precedence over a lower gap
+	ctx.Cfg.Stagger.Spread = true
+	sched.resumes = map[string]*scheduledResume{"abc": first, "def": second}
+
+	err = sched.exclusiveBoost(ctx, sess, second, &time.Time{})
+	if !errors.As(err, &staggered) || staggered.wait <= ctx.Cfg.BoostInterval/2-time.Minute {
+		t.Errorf("invalid stagger delay for spread boosts: %v", err)
+	}
+
+	// The boosts are spread across the shortest interval of the resumes
+	first.policy.interval = ctx.Cfg.BoostInterval
+	second.policy.interval = time.Hour
+
+	err = sched.exclusiveBoost(ctx, sess, second, &time.Time{})
+	if !errors.As(err, &staggered) || staggered.wait <= 29*time.Minute || staggered.wait > 30*time.Minute {
+		t.Errorf("invalid stagger delay for an overridden interval: %v", err)
+	}
+
+	// Manual boosts are not delayed
+	if err := sched.exclusiveBoost(ctx, sess, second, nil); err != nil {
+		t.Errorf("boosting second resume manually: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// stateSchemaVersion is the version of the state file format.
+// It must be incremented whenever the format changes in an incompatible way.
+const stateSchemaVersion = 1
+
+// resumeState is the persistent boost state of a single resume.
+type resumeState struct {
+	LastAttempt time.Time `json:"last_attempt,omitzero"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+
+	// Failures is the number of consecutive failed boost attempts since the last success
+	Failures int `json:"failures"`
+
+	NextBoost time.Time `json:"next_boost,omitzero"`
+}
+
+// stateFile is the on-disk representation of the state store.
+type stateFile struct {
+	Version int                    `json:"version"`
+	Resumes map[string]resumeState `json:"resumes"`
+}
+
+// stateStore persists the boost state across restarts,
+// so that we can tell our own boosts from the ones made by the user.
+type stateStore struct {
+	// fileName is empty if the state is not persisted
+	fileName string
+
+	resumes map[string]resumeState
+	mu      sync.Mutex
+}
+
+// loadStateStore loads the state from a file.
+// A missing file results in an empty state.
+// If fileName is empty, the state is kept in memory only.
+func loadStateStore(fileName string) (*stateStore, error) {
+	st := &stateStore{
+		resumes: map[string]resumeState{},
+	}
+
+	if fileName == "" {
+		return st, nil
+	}
+
+	st.fileName = filepath.Clean(fileName)
+
+	data, err := os.ReadFile(st.fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading state file: %w", err)
+	}
+
+	var sf stateFile
+	err = json.Unmarshal(data, &sf)
+	if err != nil {
+		return nil, fmt.Errorf("decoding state file: %w", err)
+	}
+
+	if sf.Version != stateSchemaVersion {
+		return nil, fmt.Errorf("unsupported state file version: %v (expected %v)", sf.Version, stateSchemaVersion)
+	}
+
+	if sf.Resumes != nil {
+		st.resumes = sf.Resumes
+	}
+
+	return st, nil
+}
+
+// get returns the state of a resume.
+func (st *stateStore) get(id string) (resumeState, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	rs, ok := st.resumes[id]
+	return rs, ok
+}
+
+// update modifies the state of a resume and persists the state.
+func (st *stateStore) update(id string, fn func(rs *resumeState)) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	rs := st.resumes[id]
+	fn(&rs)
+	st.resumes[id] = rs
+
+	return st.save()
+}
+
+// remove forgets the state of a resume.
+func (st *stateStore) remove(id string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if _, ok := st.resumes[id]; !ok {
+		return nil
+	}
+
+	delete(st.resumes, id)
+	return st.save()
+}
+
+// save atomically writes the state to the file:
+// the state is written to a temporary file, which then replaces the original one.
+// The caller must hold mu.
+func (st *stateStore) save() error {
+	if st.fileName == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(&stateFile{
+		Version: stateSchemaVersion,
+		Resumes: st.resumes,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(st.fileName), filepath.Base(st.fileName)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating temporary state file: %w", err)
+	}
+
+	tmpName := f.Name()
+
+	// Clean up the temporary file if anything goes wrong
+	committed := false
+	defer func() {
+		if !committed {
+			_ = f.Close()
+			_ = os.Remove(tmpName)
+		}
+	}()
+
+	_, err = f.Write(data)
+	if err != nil {
+		return fmt.Errorf("writing temporary state file: %w", err)
+	}
+
+	err = f.Sync()
+	if err != nil {
+		return fmt.Errorf("syncing temporary state file: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("closing temporary state file: %w", err)
+	}
+
+	err = os.Rename(tmpName, st.fileName)
+	if err != nil {
+		return fmt.Errorf("replacing state file: %w", err)
+	}
+
+	committed = true
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestStateStore checks that the boost state survives a reload.
+func TestStateStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "state.json")
+
+	st, err := loadStateStore(path)
+	if err != nil {
+		t.Fatalf("loading missing state file: %v", err)
+	}
+
+	success := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	err = st.update("abc", func(rs *resumeState) {
+		rs.LastAttempt = success
+		rs.LastSuccess = success
+		rs.NextBoost = success.Add(4 * time.Hour)
+	})
+	if err != nil {
+		t.Fatalf("updating state: %v", err)
+	}
+
+	err = st.update("def", func(rs *resumeState) {
+		rs.Failures++
+	})
+	if err != nil {
+		t.Fatalf("updating state: %v", err)
+	}
+
+	st, err = loadStateStore(path)
+	if err != nil {
+		t.Fatalf("reloading state: %v", err)
+	}
+
+	rs, ok := st.get("abc")
+	if !ok {
+		t.Fatal("missing resume state after reload")
+	}
+
+	if !rs.LastSuccess.Equal(success) || !rs.NextBoost.Equal(success.Add(4*time.Hour)) {
+		t.Errorf("invalid resume state: %+v", rs)
+	}
+
+	if rs, _ := st.get("def"); rs.Failures != 1 {
+		t.Errorf("invalid failure count: got %v, expected 1", rs.Failures)
+	}
+
+	if err := st.remove("def"); err != nil {
+		t.Fatalf("removing resume state: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("reading temp dir: %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("temporary files have been left behind: %v", entries)
+	}
+}
+
+// TestStateStoreVersion checks that state files of unknown versions are rejected.
+func TestStateStoreVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"version": 999, "resumes": {}}`), 0o600); err != nil {
+		t.Fatalf("writing state file: %v", err)
+	}
+
+	if _, err := loadStateStore(path); err == nil {
+		t.Fatal("expected error for an unsupported version")
+	}
+}
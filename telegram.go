@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+const defaultTelegramAPIURL = "https://api.telegram.org"
+
+// telegramNotifier sends notifications through a Telegram bot.
+type telegramNotifier struct {
+	cl     *req.Client
+	apiURL string
+	token  string
+	chatID string
+}
+
+func newTelegramNotifier(ctx *AppContext) *telegramNotifier {
+	return &telegramNotifier{
+		cl:     req.C().SetTimeout(30 * time.Second),
+		apiURL: strings.TrimSuffix(ctx.Cfg.Notifications.Telegram.APIURL, "/"),
+		token:  ctx.Cfg.Notifications.Telegram.BotToken,
+		chatID: ctx.Cfg.Notifications.Telegram.ChatID,
+	}
+}
+
+func (n *telegramNotifier) name() string {
+	return "telegram"
+}
+
+// notify sends the event as a text message, or as a photo with a caption if it has a captcha image attached.
+func (n *telegramNotifier) notify(ctx context.Context, ev *event) error {
+	r := n.cl.R().SetContext(ctx)
+	method := "sendMessage"
+
+	if len(ev.Image) > 0 {
+		method = "sendPhoto"
+		r.SetFormData(map[string]string{
+			"chat_id": n.chatID,
+			"caption": ev.text(),
+		})
+		r.SetFileBytes("photo", "captcha.png", ev.Image)
+	} else {
+		r.SetBodyJsonMarshal(map[string]any{
+			"chat_id":                  n.chatID,
+			"text":                     ev.text(),
+			"disable_web_page_preview": true,
+		})
+	}
+
+	resp, err := r.Post(n.apiURL + "/bot" + n.token + "/" + method)
+	if err != nil {
+		// The bot token is a part of the URL, so it must not leak into the logs
+		return fmt.Errorf("sending HTTP request: %w", errors.New(strings.ReplaceAll(err.Error(), n.token, "<redacted>")))
+	}
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+
+	err = json.Unmarshal(resp.Bytes(), &result)
+	if err != nil {
+		return fmt.Errorf("decoding Telegram response (status code %v): %w", resp.StatusCode, err)
+	}
+
+	if !result.OK {
+		return fmt.Errorf("message has been rejected by Telegram (status code %v): %v", resp.StatusCode, result.Description)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFakeTelegramServer starts a Bot API stand-in that forwards the received messages to the channel.
+// The photos are forwarded with the contents of the file in the "photo" field.
+func newFakeTelegramServer(t *testing.T, token string) (*httptest.Server, <-chan map[string]any) {
+	t.Helper()
+
+	messages := make(chan map[string]any, 16)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]any
+
+		switch r.URL.Path {
+		case "/bot" + token + "/sendMessage":
+			if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"ok": false, "description": "Bad Request"}`))
+				return
+			}
+
+		case "/bot" + token + "/sendPhoto":
+			photo, _, err := r.FormFile("photo")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"ok": false, "description": "Bad Request: there is no photo in the request"}`))
+				return
+			}
+
+			data, _ := io.ReadAll(photo)
+			msg = map[string]any{
+				"chat_id": r.FormValue("chat_id"),
+				"caption": r.FormValue("caption"),
+				"photo":   string(data),
+			}
+
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"ok": false, "description": "Unauthorized"}`))
+			return
+		}
+
+		messages <- msg
+		_, _ = w.Write([]byte(`{"ok": true, "result": {}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, messages
+}
+
+// TestTelegramNotifier checks that events are delivered to the Bot API.
+func TestTelegramNotifier(t *testing.T) {
+	const token = "123456:secret-token"
+
+	srv, messages := newFakeTelegramServer(t, token)
+
+	ctx := newTestAppContext(t, "https://hh.ru")
+	ctx.Cfg.Name = "test"
+	ctx.Cfg.Notifications.Telegram.BotToken = token
+	ctx.Cfg.Notifications.Telegram.ChatID = "42"
+	ctx.Cfg.Notifications.Telegram.APIURL = srv.URL + "/"
+	ctx.Context = t.Context()
+
+	t.Run("delivery", func(t *testing.T) {
+		hub := newNotificationHubFromConfig(ctx)
+		hub.run(ctx)
+
+		hub.publish(ctx, event{Kind: eventBoostSucceeded, ResumeID: "abc", ResumeTitle: "Go developer"})
+
+		select {
+		case msg := <-messages:
+			if msg["chat_id"] != "42" {
+				t.Errorf("invalid chat ID: got %v, expected 42", msg["chat_id"])
+			}
+
+			expected := `[test] Resume "Go developer" has been boosted`
+			if msg["text"] != expected {
+				t.Errorf("invalid message text: got %q, expected %q", msg["text"], expected)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("message has not been delivered")
+		}
+	})
+
+	t.Run("captcha image", func(t *testing.T) {
+		n := newTelegramNotifier(ctx)
+
+		ev := &event{Kind: eventCaptchaRequired, Account: "test", Image: []byte("\x89PNG\r\n\x1a\ncaptcha")}
+		if err := n.notify(t.Context(), ev); err != nil {
+			t.Fatalf("sending photo: %v", err)
+		}
+
+		msg := <-messages
+		if msg["chat_id"] != "42" {
+			t.Errorf("invalid chat ID: got %v, expected 42", msg["chat_id"])
+		}
+
+		if msg["photo"] != string(ev.Image) {
+			t.Errorf("invalid photo: got %q, expected %q", msg["photo"], ev.Image)
+		}
+
+		expected := "[test] HH requires a captcha to log in"
+		if msg["caption"] != expected {
+			t.Errorf("invalid caption: got %q, expected %q", msg["caption"], expected)
+		}
+	})
+
+	t.Run("rejected message", func(t *testing.T) {
+		n := newTelegramNotifier(ctx)
+		n.token = "654321:wrong-token"
+
+		err := n.notify(t.Context(), &event{Kind: eventCaptchaRequired})
+		if err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+	})
+
+	t.Run("token redaction", func(t *testing.T) {
+		n := newTelegramNotifier(ctx)
+		n.apiURL = "http://127.0.0.1:1"
+
+		err := n.notify(t.Context(), &event{Kind: eventCaptchaRequired})
+		if err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+
+		if strings.Contains(err.Error(), token) {
+			t.Errorf("bot token has leaked into the error: %v", err)
+		}
+	})
+}
@@ -2,9 +2,8 @@ package main
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
-
-	"github.com/imroc/req/v3"
 )
 
 func buildHHURL(ctx *AppContext, pathWithQuery string) string {
@@ -26,8 +25,8 @@ func buildHHURL(ctx *AppContext, pathWithQuery string) string {
 	return u1.String()
 }
 
-func getXSRFToken(resp *req.Response) string {
-	for _, cookie := range resp.Cookies() {
+func getXSRFToken(cookies []*http.Cookie) string {
+	for _, cookie := range cookies {
 		if cookie.Name == "_xsrf" {
 			return cookie.Value
 		}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/imroc/req/v3"
+)
+
+// maxWebhookRetryDelay caps the exponential backoff between webhook delivery attempts.
+const maxWebhookRetryDelay = 5 * time.Minute
+
+// webhookNotifier POSTs events to an arbitrary URL.
+type webhookNotifier struct {
+	cl  *req.Client
+	url string
+
+	// tmpl renders the request body; if nil, the event is sent as JSON
+	tmpl *template.Template
+
+	headers map[string]string
+
+	// secret is used to sign the request body; signing is disabled if it is empty
+	secret string
+
+	maxAttempts int
+	retryDelay  time.Duration
+}
+
+// newWebhookNotifier creates a webhook notifier.
+// The config must have been validated beforehand, so that the template is known to be valid.
+func newWebhookNotifier(ctx *AppContext) *webhookNotifier {
+	cfg := &ctx.Cfg.Notifications.Webhook
+
+	n := &webhookNotifier{
+		cl:          req.C().SetTimeout(30 * time.Second),
+		url:         cfg.URL,
+		headers:     cfg.Headers,
+		secret:      cfg.Secret,
+		maxAttempts: cfg.MaxAttempts,
+		retryDelay:  cfg.RetryDelay,
+	}
+
+	if cfg.Template != "" {
+		n.tmpl = template.Must(parseWebhookTemplate(cfg.Template))
+	}
+
+	return n
+}
+
+// parseWebhookTemplate parses a user-defined webhook body template.
+// The template may use the base64 function to embed the captcha image.
+func parseWebhookTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"base64": base64.StdEncoding.EncodeToString}).
+		Parse(text)
+}
+
+func (n *webhookNotifier) name() string {
+	return "webhook"
+}
+
+func (n *webhookNotifier) notify(ctx context.Context, ev *event) error {
+	body, err := n.render(ev)
+	if err != nil {
+		return err
+	}
+
+	delay := n.retryDelay
+
+	for attempt := 1; ; attempt++ {
+		retry, err := n.send(ctx, body)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= n.maxAttempts {
+			return fmt.Errorf("delivering webhook (attempt %v of %v): %w", attempt, n.maxAttempts, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("delivering webhook: %w", ctx.Err())
+		}
+
+		delay = min(2*delay, maxWebhookRetryDelay)
+	}
+}
+
+// render builds the request body for the event.
+func (n *webhookNotifier) render(ev *event) ([]byte, error) {
+	if n.tmpl == nil {
+		body, err := json.Marshal(ev)
+		if err != nil {
+			return nil, fmt.Errorf("encoding event: %w", err)
+		}
+
+		return body, nil
+	}
+
+	buf := &bytes.Buffer{}
+
+	err := n.tmpl.Execute(buf, ev)
+	if err != nil {
+		return nil, fmt.Errorf("rendering webhook template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// send makes a single delivery attempt.
+// It reports whether the attempt may be retried if it has failed.
+func (n *webhookNotifier) send(ctx context.Context, body []byte) (bool, error) {
+	r := n.cl.R().SetContext(ctx)
+	r.SetHeader("Content-Type", "application/json")
+	r.SetHeaders(n.headers)
+	r.SetBodyBytes(body)
+
+	if n.secret != "" {
+		r.SetHeader("X-Signature-256", webhookSignature(n.secret, body))
+	}
+
+	resp, err := r.Post(n.url)
+	if err != nil {
+		return true, fmt.Errorf("sending HTTP request: %w", err)
+	}
+
+	if resp.IsSuccessState() {
+		return false, nil
+	}
+
+	// Client errors are not going to go away by themselves, except for rate limiting
+	retry := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("received an HTTP error: status code %v", resp.StatusCode)
+}
+
+// webhookSignature computes the value of the X-Signature-256 header:
+// an HMAC-SHA256 of the body, keyed with the secret.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWebhookNotifier checks the webhook payloads, signatures and retries.
+func TestWebhookNotifier(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   []byte
+	}
+
+	newServer := func(t *testing.T, statuses ...int) (*httptest.Server, chan request, *atomic.Int32) {
+		t.Helper()
+
+		requests := make(chan request, 16)
+		attempts := &atomic.Int32{}
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := int(attempts.Add(1))
+			body, _ := io.ReadAll(r.Body)
+			requests <- request{r.Header, body}
+
+			if n <= len(statuses) {
+				w.WriteHeader(statuses[n-1])
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		return srv, requests, attempts
+	}
+
+	newContext := func(t *testing.T, url string) *AppContext {
+		t.Helper()
+
+		ctx := newTestAppContext(t, "https://hh.ru")
+		ctx.Cfg.Name = "test"
+		ctx.Cfg.Notifications.Webhook.URL = url
+		ctx.Cfg.Notifications.Webhook.RetryDelay = time.Millisecond
+
+		return ctx
+	}
+
+	ev := &event{Kind: eventBoostFailed, Account: "test", ResumeID: "abc", ResumeTitle: "Go developer", Error: "HTTP 500"}
+	ev.Text = ev.text()
+
+	t.Run("json payload", func(t *testing.T) {
+		srv, requests, _ := newServer(t)
+		ctx := newContext(t, srv.URL)
+		ctx.Cfg.Notifications.Webhook.Secret = "secret"
+		ctx.Cfg.Notifications.Webhook.Headers = map[string]string{"Authorization": "Bearer token"}
+
+		if err := newWebhookNotifier(ctx).notify(t.Context(), ev); err != nil {
+			t.Fatalf("sending webhook: %v", err)
+		}
+
+		req := <-requests
+
+		var got event
+		if err := json.Unmarshal(req.body, &got); err != nil {
+			t.Fatalf("decoding payload: %v", err)
+		}
+
+		if got.Kind != eventBoostFailed || got.ResumeID != "abc" || got.Text != ev.Text {
+			t.Errorf("invalid payload: %+v", got)
+		}
+
+		if sig := req.header.Get("X-Signature-256"); sig != webhookSignature("secret", req.body) {
+			t.Errorf("invalid signature: %q", sig)
+		}
+
+		if auth := req.header.Get("Authorization"); auth != "Bearer token" {
+			t.Errorf("invalid custom header: got %q, expected %q", auth, "Bearer token")
+		}
+	})
+
+	t.Run("templated payload", func(t *testing.T) {
+		srv, requests, _ := newServer(t)
+		ctx := newContext(t, srv.URL)
+		ctx.Cfg.Notifications.Webhook.Template = `{"content": {{ printf "%q" .Text }}, "resume": "{{ .ResumeID }}"}`
+
+		if err := newWebhookNotifier(ctx).notify(t.Context(), ev); err != nil {
+			t.Fatalf("sending webhook: %v", err)
+		}
+
+		req := <-requests
+
+		var got map[string]string
+		if err := json.Unmarshal(req.body, &got); err != nil {
+			t.Fatalf("decoding payload %q: %v", req.body, err)
+		}
+
+		if got["content"] != ev.Text || got["resume"] != "abc" {
+			t.Errorf("invalid payload: %v", got)
+		}
+	})
+
+	t.Run("captcha image", func(t *testing.T) {
+		srv, requests, _ := newServer(t)
+		ctx := newContext(t, srv.URL)
+		ctx.Cfg.Notifications.Webhook.Template = `{"image": "{{ base64 .Image }}"}`
+
+		captchaEv := &event{Kind: eventCaptchaRequired, Account: "test", Image: []byte("\x89PNG\r\n\x1a\ncaptcha")}
+		captchaEv.Text = captchaEv.text()
+
+		if err := newWebhookNotifier(ctx).notify(t.Context(), captchaEv); err != nil {
+			t.Fatalf("sending templated webhook: %v", err)
+		}
+
+		ctx.Cfg.Notifications.Webhook.Template = ""
+		if err := newWebhookNotifier(ctx).notify(t.Context(), captchaEv); err != nil {
+			t.Fatalf("sending webhook: %v", err)
+		}
+
+		for range 2 {
+			req := <-requests
+
+			var got struct {
+				Image []byte `json:"image"`
+			}
+			if err := json.Unmarshal(req.body, &got); err != nil {
+				t.Fatalf("decoding payload %q: %v", req.body, err)
+			}
+
+			if !bytes.Equal(got.Image, captchaEv.Image) {
+				t.Errorf("invalid captcha image: got %q, expected %q", got.Image, captchaEv.Image)
+			}
+		}
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		srv, _, attempts := newServer(t, http.StatusServiceUnavailable, http.StatusBadGateway)
+		ctx := newContext(t, srv.URL)
+
+		if err := newWebhookNotifier(ctx).notify(t.Context(), ev); err != nil {
+			t.Fatalf("sending webhook: %v", err)
+		}
+
+		if n := attempts.Load(); n != 3 {
+			t.Errorf("invalid number of attempts: got %v, expected 3", n)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		srv, _, attempts := newServer(t, 500, 500, 500, 500, 500)
+		ctx := newContext(t, srv.URL)
+		ctx.Cfg.Notifications.Webhook.MaxAttempts = 2
+
+		if err := newWebhookNotifier(ctx).notify(t.Context(), ev); err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+
+		if n := attempts.Load(); n != 2 {
+			t.Errorf("invalid number of attempts: got %v, expected 2", n)
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		srv, _, attempts := newServer(t, http.StatusBadRequest)
+		ctx := newContext(t, srv.URL)
+
+		if err := newWebhookNotifier(ctx).notify(t.Context(), ev); err == nil {
+			t.Fatal("expected an error but got nil")
+		}
+
+		if n := attempts.Load(); n != 1 {
+			t.Errorf("invalid number of attempts: got %v, expected 1", n)
+		}
+	})
+}
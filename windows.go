@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	// The Docker image does not ship the time zone database
+	_ "time/tzdata"
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// boostWindow is a daily time range on specific weekdays.
+type boostWindow struct {
+	days [7]bool
+
+	// start and end are the offsets from midnight, in minutes
+	start int
+	end   int
+}
+
+// boostWindows restricts the boosts to a set of time windows.
+type boostWindows struct {
+	loc     *time.Location
+	windows []boostWindow
+}
+
+// parseBoostWindows parses the boost windows from the config.
+// If there are no windows configured, it returns nil.
+func parseBoostWindows(cfg *Config) (*boostWindows, error) {
+	if len(cfg.BoostWindows.Windows) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	loc, err := loadTimeZone(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	bw := &boostWindows{loc: loc}
+
+	for i, w := range cfg.BoostWindows.Windows {
+		window, err := parseBoostWindow(&w)
+		if err != nil {
+			return nil, fmt.Errorf("window #%v: %w", i+1, err)
+		}
+
+		bw.windows = append(bw.windows, window)
+	}
+
+	return bw, nil
+}
+
+// loadTimeZone returns the time zone of the boost windows and holidays.
+func loadTimeZone(cfg *Config) (*time.Location, error) {
+	if cfg.BoostWindows.TimeZone == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(cfg.BoostWindows.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("loading time zone: %w", err)
+	}
+
+	return loc, nil
+}
+
+func parseBoostWindow(w *BoostWindow) (boostWindow, error) {
+	window := boostWindow{}
+
+	if len(w.Days) == 0 {
+		for day := range window.days {
+			window.days[day] = true
+		}
+	}
+
+	for _, days := range w.Days {
+		first, last, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(days)), "-")
+
+		from, ok := weekdayNames[strings.TrimSpace(first)]
+		if !ok {
+			return window, fmt.Errorf("invalid weekday: %q", days)
+		}
+
+		to := from
+		if isRange {
+			to, ok = weekdayNames[strings.TrimSpace(last)]
+			if !ok {
+				return window, fmt.Errorf("invalid weekday: %q", days)
+			}
+		}
+
+		// Ranges may wrap around the end of the week, e.g. "sat-mon"
+		for day := from; ; day = (day + 1) % 7 {
+			window.days[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+
+	var err error
+
+	window.start, err = parseClockTime(w.Start)
+	if err != nil {
+		return window, fmt.Errorf("parsing start time: %w", err)
+	}
+
+	window.end, err = parseClockTime(w.End)
+	if err != nil {
+		return window, fmt.Errorf("parsing end time: %w", err)
+	}
+
+	if window.start >= window.end {
+		return window, errors.New("window must end after it starts")
+	}
+
+	return window, nil
+}
+
+// parseClockTime parses a time of day in the HH:MM format
+// and returns the number of minutes since midnight.
+func parseClockTime(s string) (int, error) {
+	if s == "24:00" {
+		return 24 * 60, nil
+	}
+
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (must be HH:MM)", s)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// bounds returns the start and the end of the window on the specified day.
+func (w *boostWindow) bounds(day time.Time) (time.Time, time.Time) {
+	y, m, d := day.Date()
+
+	// time.Date normalizes the overflowing minutes (i.e. 24:00 becomes midnight of the next day)
+	// and takes care of DST transitions
+	start := time.Date(y, m, d, 0, w.start, 0, 0, day.Location())
+	end := time.Date(y, m, d, 0, w.end, 0, 0, day.Location())
+
+	return start, end
+}
+
+// days returns the midnights of the days from the one that contains t and a week ahead,
+// which is enough to find any window.
+func (bw *boostWindows) days(t time.Time) []time.Time {
+	y, m, d := t.In(bw.loc).Date()
+
+	days := make([]time.Time, 0, 8)
+	for i := range 8 {
+		days = append(days, time.Date(y, m, d+i, 0, 0, 0, 0, bw.loc))
+	}
+
+	return days
+}
+
+// next returns the earliest time that is not before t and falls inside a window.
+//
+// If the boosts follow each other at a fixed interval, boosting as early as the windows allow
+// also maximizes the number of boosts that fall inside them: an earlier boost never forces
+// any of the subsequent boosts to happen later. This does not hold once random delays are added.
+func (bw *boostWindows) next(t time.Time) time.Time {
+	for _, day := range bw.days(t) {
+		var earliest time.Time
+
+		for _, w := range bw.windows {
+			if !w.days[day.Weekday()] {
+				continue
+			}
+
+			start, end := w.bounds(day)
+			if !t.Before(end) {
+				continue
+			}
+
+			candidate := start
+			if t.After(start) {
+				candidate = t
+			}
+
+			if earliest.IsZero() || candidate.Before(earliest) {
+				earliest = candidate
+			}
+		}
+
+		if !earliest.IsZero() {
+			return earliest
+		}
+	}
+
+	// Unreachable: every window applies to at least one weekday
+	return t
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBoostWindows checks that the boosts are deferred to the boost windows.
+func TestBoostWindows(t *testing.T) {
+	cfg := Config{}
+	cfg.BoostWindows.TimeZone = "Europe/Moscow"
+	cfg.BoostWindows.Windows = []BoostWindow{
+		{Days: []string{"mon-fri"}, Start: "08:00", End: "20:00"},
+		{Days: []string{"Sat"}, Start: "10:00", End: "24:00"},
+	}
+
+	bw, err := parseBoostWindows(&cfg)
+	if err != nil {
+		t.Fatalf("parsing boost windows: %v", err)
+	}
+
+	msk, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Fatalf("loading time zone: %v", err)
+	}
+
+	at := func(day, hour, minute int) time.Time {
+		// 2026-01-05 is a Monday
+		return time.Date(2026, 1, 4+day, hour, minute, 0, 0, msk)
+	}
+
+	tests := []struct {
+		name     string
+		due      time.Time
+		expected time.Time
+	}{
+		{"inside a window", at(1, 12, 30), at(1, 12, 30)},
+		{"at the window start", at(1, 8, 0), at(1, 8, 0)},
+		{"before the window start", at(1, 3, 0), at(1, 8, 0)},
+		{"at the window end", at(1, 20, 0), at(2, 8, 0)},
+		{"friday night", at(5, 23, 0), at(6, 10, 0)},
+		{"saturday night", at(6, 23, 59), at(6, 23, 59)},
+		{"sunday", at(7, 12, 0), at(8, 8, 0)},
+		{"other time zone", time.Date(2026, 1, 5, 3, 0, 0, 0, time.UTC), at(1, 8, 0)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if next := bw.next(test.due); !next.Equal(test.expected) {
+				t.Errorf("invalid boost time: got %v, expected %v", next, test.expected)
+			}
+		})
+	}
+}
+
+// TestBoostWindowsFitMaximum checks that, for a fixed boost interval without jitter,
+// deferring the boosts to the windows fits the maximum number of boosts into them:
+// delaying any of the boosts beyond what the windows require never lets more boosts in.
+func TestBoostWindowsFitMaximum(t *testing.T) {
+	cfg := Config{}
+	cfg.BoostWindows.TimeZone = "UTC"
+	cfg.BoostWindows.Windows = []BoostWindow{
+		{Days: []string{"mon-fri"}, Start: "08:00", End: "20:00"},
+		{Days: []string{"sat"}, Start: "10:00", End: "14:00"},
+	}
+
+	bw, err := parseBoostWindows(&cfg)
+	if err != nil {
+		t.Fatalf("parsing boost windows: %v", err)
+	}
+
+	// 2026-01-05 is a Monday
+	start := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+
+	// count plans the boosts of a week, delaying the boost #delayed by delay
+	count := func(interval time.Duration, delayed int, delay time.Duration) int {
+		n := 0
+		for t := bw.next(start); t.Before(end); t = bw.next(t.Add(interval)) {
+			if n == delayed {
+				t = bw.next(t.Add(delay))
+				if !t.Before(end) {
+					break
+				}
+			}
+
+			n++
+		}
+
+		return n
+	}
+
+	for _, interval := range []time.Duration{4 * time.Hour, 5 * time.Hour, 7*time.Hour + 30*time.Minute} {
+		expected := count(interval, -1, 0)
+
+		for delayed := range expected {
+			for delay := 15 * time.Minute; delay < interval; delay += 15 * time.Minute {
+				if n := count(interval, delayed, delay); n > expected {
+					t.Errorf("invalid number of boosts for %v: got %v, expected at most %v", interval, n, expected)
+				}
+			}
+		}
+	}
+}
+
+// TestParseBoostWindows checks that invalid boost windows are rejected.
+func TestParseBoostWindows(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeZone string
+		window   BoostWindow
+	}{
+		{"unknown time zone", "Mars/Olympus_Mons", BoostWindow{Start: "08:00", End: "20:00"}},
+		{"unknown weekday", "", BoostWindow{Days: []string{"monday-fri"}, Start: "08:00", End: "20:00"}},
+		{"invalid start", "", BoostWindow{Start: "8", End: "20:00"}},
+		{"invalid end", "", BoostWindow{Start: "08:00", End: "24:30"}},
+		{"ends before start", "", BoostWindow{Start: "20:00", End: "08:00"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := Config{}
+			cfg.BoostWindows.TimeZone = test.timeZone
+			cfg.BoostWindows.Windows = []BoostWindow{test.window}
+
+			if _, err := parseBoostWindows(&cfg); err == nil {
+				t.Error("expected an error but got nil")
+			}
+		})
+	}
+
+	if bw, err := parseBoostWindows(&Config{}); bw != nil || err != nil {
+		t.Errorf("invalid result for no windows: got %v, %v", bw, err)
+	}
+}